@@ -0,0 +1,420 @@
+package nfce
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultNamePattern é o padrão de nome dos arquivos gerados em lote
+const DefaultNamePattern = "{chave}.{ext}"
+
+// BatchOptions contém as opções para geração de DANFEs em lote
+type BatchOptions struct {
+	Format Format
+	Copies CopiesMode
+
+	// OutputDir é o diretório onde os arquivos são gravados. É criado se não existir.
+	OutputDir string
+
+	// NamePattern define o nome dos arquivos gerados. Aceita {chave}, {numero},
+	// {serie}, {arquivo} (nome do XML sem extensão) e {ext}; dos valores são
+	// mantidos apenas letras, dígitos, "_" e "-". Nomes fora de OutputDir e
+	// repetidos no lote terminam com BatchFailed. Padrão: DefaultNamePattern.
+	NamePattern string
+
+	// Workers é o número de arquivos processados em paralelo (padrão: runtime.NumCPU())
+	Workers int
+
+	// GeneratorOptions são aplicadas a cada Generator criado
+	GeneratorOptions []Option
+
+	// Progress é chamada após cada arquivo processado. As chamadas são
+	// sequenciais, nunca concorrentes.
+	Progress func(BatchProgress)
+}
+
+// BatchStatus classifica o resultado de um arquivo do lote
+type BatchStatus int
+
+const (
+	// BatchSucceeded indica que o DANFE foi gerado e gravado
+	BatchSucceeded BatchStatus = iota
+	// BatchValidationFailed indica XML inválido, documento que não é NFC-e ou NF-e não autorizada
+	BatchValidationFailed
+	// BatchConverterFailed indica falha na conversão para PDF (ErrConversion),
+	// inclusive a falta de conversor
+	BatchConverterFailed
+	// BatchFailed indica erros de leitura ou gravação de arquivos
+	BatchFailed
+	// BatchCanceled indica que o arquivo não foi processado, ou foi
+	// interrompido, porque o contexto foi cancelado
+	BatchCanceled
+)
+
+// String retorna a descrição da situação
+func (s BatchStatus) String() string {
+	switch s {
+	case BatchSucceeded:
+		return "sucesso"
+	case BatchValidationFailed:
+		return "falha de validação"
+	case BatchConverterFailed:
+		return "falha na conversão"
+	case BatchCanceled:
+		return "cancelado"
+	default:
+		return "erro"
+	}
+}
+
+// BatchResult é o resultado do processamento de um arquivo do lote
+type BatchResult struct {
+	Source string // caminho do XML (ou nome da entrada no ZIP)
+	Output string // caminho do arquivo gerado, vazio em caso de falha
+	Chave  string
+	Status BatchStatus
+	Err    error
+}
+
+// BatchProgress informa o andamento do lote
+type BatchProgress struct {
+	Done   int
+	Total  int
+	Result BatchResult
+}
+
+// BatchReport reúne os resultados do lote, na ordem dos arquivos de entrada
+type BatchReport struct {
+	Results []BatchResult
+}
+
+// Count retorna quantos arquivos terminaram com a situação informada
+func (r *BatchReport) Count(status BatchStatus) int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed retorna os resultados que não terminaram com sucesso
+func (r *BatchReport) Failed() []BatchResult {
+	var failed []BatchResult
+	for _, res := range r.Results {
+		if res.Status != BatchSucceeded {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// batchInput é um XML a ser processado
+type batchInput struct {
+	name string
+	open func() (io.ReadCloser, error)
+}
+
+// GenerateBatch gera os DANFEs de todos os XMLs de source, que pode ser um
+// diretório (percorrido recursivamente), um padrão glob ("notas/*.xml") ou um
+// arquivo ZIP. Falhas em um arquivo não interrompem o lote: cada arquivo
+// recebe um BatchResult no relatório.
+//
+// Quando ctx é cancelado, os arquivos restantes são marcados como
+// BatchCanceled e o relatório parcial é retornado junto com ctx.Err().
+func GenerateBatch(ctx context.Context, source string, options BatchOptions) (*BatchReport, error) {
+	inputs, closeSource, err := batchInputs(source)
+	if err != nil {
+		return nil, err
+	}
+	defer closeSource()
+
+	if options.NamePattern == "" {
+		options.NamePattern = DefaultNamePattern
+	}
+	if options.Format == "" {
+		options.Format = FormatHTML
+	}
+	if options.OutputDir != "" {
+		if err := os.MkdirAll(options.OutputDir, 0755); err != nil {
+			return nil, fmt.Errorf("erro ao criar diretório de saída: %w", err)
+		}
+	}
+	workers := options.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	report := &BatchReport{Results: make([]BatchResult, len(inputs))}
+	names := &batchNames{claimed: map[string]string{}}
+	jobs := make(chan int)
+	done := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				report.Results[i] = generateBatchItem(ctx, inputs[i], options, names)
+				done <- i
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i := range inputs {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	processed := make([]bool, len(inputs))
+	count := 0
+	for i := range done {
+		processed[i] = true
+		count++
+		if options.Progress != nil {
+			options.Progress(BatchProgress{Done: count, Total: len(inputs), Result: report.Results[i]})
+		}
+	}
+
+	for i, ok := range processed {
+		if !ok {
+			report.Results[i] = BatchResult{Source: inputs[i].name, Status: BatchCanceled, Err: ctx.Err()}
+		}
+	}
+	if report.Count(BatchCanceled) > 0 {
+		return report, ctx.Err()
+	}
+	return report, nil
+}
+
+// generateBatchItem gera o DANFE de um arquivo do lote
+func generateBatchItem(ctx context.Context, input batchInput, options BatchOptions, names *batchNames) BatchResult {
+	result := BatchResult{Source: input.name}
+
+	fail := func(status BatchStatus, err error) BatchResult {
+		result.Status = status
+		result.Err = err
+		return result
+	}
+
+	r, err := input.open()
+	if err != nil {
+		return fail(BatchFailed, fmt.Errorf("erro ao abrir arquivo XML: %w", err))
+	}
+	xmlContent, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return fail(BatchFailed, fmt.Errorf("erro ao ler arquivo XML: %w", err))
+	}
+
+	generator, err := NewGenerator(xmlContent, options.GeneratorOptions...)
+	if err != nil {
+		return fail(classifyBatchError(err), err)
+	}
+	result.Chave = generator.nfe.GetChaveAcesso()
+	if !generator.IsNFCe() {
+		return fail(BatchValidationFailed, ErrNotNFCe)
+	}
+
+	var buf bytes.Buffer
+	if err := generator.GenerateToWriterContext(ctx, &buf, GenerateOptions{Format: options.Format, Copies: options.Copies}); err != nil {
+		return fail(classifyBatchError(err), err)
+	}
+
+	output, err := batchOutputPath(options.OutputDir, batchOutputName(options.NamePattern, input.name, generator, options.Format))
+	if err != nil {
+		return fail(BatchFailed, err)
+	}
+	if err := names.claim(output, input.name); err != nil {
+		return fail(BatchFailed, err)
+	}
+	if dir := filepath.Dir(output); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fail(BatchFailed, fmt.Errorf("erro ao criar diretório de saída: %w", err))
+		}
+	}
+	if err := os.WriteFile(output, buf.Bytes(), 0644); err != nil {
+		return fail(BatchFailed, fmt.Errorf("erro ao salvar arquivo: %w", err))
+	}
+
+	result.Output = output
+	return result
+}
+
+// classifyBatchError separa falhas de validação do documento das falhas de
+// conversão. Arquivos interrompidos pelo cancelamento do contexto são
+// BatchCanceled, mesmo quando o erro vem do conversor.
+func classifyBatchError(err error) BatchStatus {
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return BatchCanceled
+	case errors.Is(err, ErrMalformedXML), errors.Is(err, ErrNotNFCe),
+		errors.Is(err, ErrNotAuthorized), errors.Is(err, ErrUnsupportedFormat):
+		return BatchValidationFailed
+	case errors.Is(err, ErrConversion):
+		return BatchConverterFailed
+	default:
+		return BatchFailed
+	}
+}
+
+// batchOutputName aplica o padrão de nome ao arquivo gerado. Os valores
+// vêm do XML e do nome do arquivo de entrada, então só os caracteres
+// [0-9A-Za-z_-] são mantidos.
+func batchOutputName(pattern, source string, g *Generator, format Format) string {
+	base := path.Base(filepath.ToSlash(source))
+	replacer := strings.NewReplacer(
+		"{chave}", safeName(g.nfe.GetChaveAcesso()),
+		"{numero}", safeName(g.nfe.GetNumeroNF()),
+		"{serie}", safeName(g.nfe.GetSerieNF()),
+		"{arquivo}", safeName(strings.TrimSuffix(base, path.Ext(base))),
+		"{ext}", safeName(string(format)),
+	)
+	return replacer.Replace(pattern)
+}
+
+// safeName remove os caracteres que não podem fazer parte de um nome de
+// arquivo gerado a partir do XML
+func safeName(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r == '_', r == '-':
+			return r
+		default:
+			return -1
+		}
+	}, value)
+}
+
+// batchOutputPath junta o diretório de saída e o nome gerado, recusando
+// nomes que saiam do diretório
+func batchOutputPath(outputDir, name string) (string, error) {
+	output := filepath.Join(outputDir, name)
+	rel, err := filepath.Rel(filepath.Join(outputDir, "."), output)
+	if err != nil || filepath.IsAbs(name) || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("nome de arquivo inválido: %q", name)
+	}
+	return output, nil
+}
+
+// batchNames registra os arquivos gravados pelo lote, para que dois XMLs
+// não gravem no mesmo arquivo
+type batchNames struct {
+	mu      sync.Mutex
+	claimed map[string]string // arquivo de saída -> XML de origem
+}
+
+// claim reserva o arquivo de saída para source
+func (n *batchNames) claim(output, source string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if other, ok := n.claimed[output]; ok {
+		return fmt.Errorf("arquivo %s já gerado a partir de %s", output, other)
+	}
+	n.claimed[output] = source
+	return nil
+}
+
+// batchInputs lista os XMLs de um diretório, padrão glob ou arquivo ZIP
+func batchInputs(source string) ([]batchInput, func(), error) {
+	noop := func() {}
+
+	info, err := os.Stat(source)
+	switch {
+	case err == nil && info.IsDir():
+		inputs, err := dirInputs(source)
+		return inputs, noop, err
+	case err == nil && strings.EqualFold(filepath.Ext(source), ".zip"):
+		return zipInputs(source)
+	case err == nil:
+		return []batchInput{fileInput(source)}, noop, nil
+	}
+
+	matches, globErr := filepath.Glob(source)
+	if globErr != nil {
+		return nil, noop, fmt.Errorf("padrão de arquivos inválido: %w", globErr)
+	}
+	if len(matches) == 0 {
+		return nil, noop, fmt.Errorf("nenhum arquivo encontrado: %s", source)
+	}
+	inputs := make([]batchInput, 0, len(matches))
+	for _, m := range matches {
+		inputs = append(inputs, fileInput(m))
+	}
+	return inputs, noop, nil
+}
+
+// dirInputs lista os XMLs de um diretório e seus subdiretórios
+func dirInputs(dir string) ([]batchInput, error) {
+	var inputs []batchInput
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && isXMLName(p) {
+			inputs = append(inputs, fileInput(p))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar diretório: %w", err)
+	}
+	return inputs, nil
+}
+
+// zipInputs lista os XMLs de um arquivo ZIP
+func zipInputs(name string) ([]batchInput, func(), error) {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, func() {}, fmt.Errorf("erro ao abrir arquivo ZIP: %w", err)
+	}
+
+	var inputs []batchInput
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !isXMLName(f.Name) {
+			continue
+		}
+		inputs = append(inputs, batchInput{name: f.Name, open: f.Open})
+	}
+	sort.Slice(inputs, func(i, j int) bool { return inputs[i].name < inputs[j].name })
+
+	return inputs, func() { zr.Close() }, nil
+}
+
+// fileInput cria a entrada do lote para um arquivo em disco
+func fileInput(name string) batchInput {
+	return batchInput{
+		name: name,
+		open: func() (io.ReadCloser, error) { return os.Open(name) },
+	}
+}
+
+// isXMLName verifica se o arquivo tem extensão .xml
+func isXMLName(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".xml")
+}
@@ -0,0 +1,341 @@
+package nfce
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/marcelo-cunha/nfce-render/converter"
+)
+
+// writeBatchInputs grava os XMLs informados em um diretório temporário
+func writeBatchInputs(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// listFiles retorna os arquivos de root, relativos a root
+func listFiles(t *testing.T, root string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		files = append(files, rel)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestGenerateBatchHostileNames(t *testing.T) {
+	xmlContent, err := os.ReadFile("testdata/nfce.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostile := bytes.Replace(xmlContent, []byte(`Id="NFe13240112345678000195650010000001231000001236"`), []byte(`Id="NFe../../fora"`), 1)
+	hostile = bytes.Replace(hostile, []byte(`<chNFe>13240112345678000195650010000001231000001236</chNFe>`), []byte(`<chNFe>../../fora</chNFe>`), 1)
+	hostile = bytes.Replace(hostile, []byte(`<serie>1</serie>`), []byte(`<serie>/tmp/x</serie>`), 1)
+
+	base := t.TempDir()
+	outputDir := filepath.Join(base, "saida")
+	source := writeBatchInputs(t, map[string][]byte{"hostil.xml": hostile})
+
+	report, err := GenerateBatch(context.Background(), source, BatchOptions{
+		OutputDir:   outputDir,
+		NamePattern: "{serie}-{chave}.{ext}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := report.Results[0]
+	if res.Status != BatchSucceeded {
+		t.Fatalf("esperado sucesso, obtido %v: %v", res.Status, res.Err)
+	}
+	if want := filepath.Join(outputDir, "tmpx-fora.html"); res.Output != want {
+		t.Errorf("Output = %s, esperado %s", res.Output, want)
+	}
+	if files := listFiles(t, base); len(files) != 1 || files[0] != filepath.Join("saida", "tmpx-fora.html") {
+		t.Errorf("arquivos gerados: %v", files)
+	}
+}
+
+func TestGenerateBatchRejectsPatternOutsideOutputDir(t *testing.T) {
+	xmlContent, err := os.ReadFile("testdata/nfce.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := writeBatchInputs(t, map[string][]byte{"nfce.xml": xmlContent})
+
+	report, err := GenerateBatch(context.Background(), source, BatchOptions{
+		OutputDir:   t.TempDir(),
+		NamePattern: "../{chave}.{ext}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := report.Results[0]; res.Status != BatchFailed || res.Output != "" {
+		t.Errorf("esperado BatchFailed sem arquivo, obtido %v %q: %v", res.Status, res.Output, res.Err)
+	}
+}
+
+func TestGenerateBatchDuplicateChaves(t *testing.T) {
+	xmlContent, err := os.ReadFile("testdata/nfce.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := writeBatchInputs(t, map[string][]byte{
+		"a.xml": xmlContent,
+		"b.xml": xmlContent,
+		"c.xml": xmlContent,
+	})
+	outputDir := t.TempDir()
+
+	report, err := GenerateBatch(context.Background(), source, BatchOptions{OutputDir: outputDir, Workers: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := report.Count(BatchSucceeded); n != 1 {
+		t.Errorf("esperado 1 sucesso, obtidos %d", n)
+	}
+	if n := report.Count(BatchFailed); n != 2 {
+		t.Errorf("esperadas 2 falhas, obtidas %d", n)
+	}
+	for _, res := range report.Failed() {
+		if res.Err == nil || res.Output != "" {
+			t.Errorf("%s: falha sem erro ou com arquivo: %+v", res.Source, res)
+		}
+	}
+	if files := listFiles(t, outputDir); len(files) != 1 {
+		t.Errorf("arquivos gerados: %v", files)
+	}
+}
+
+// sampleChave é a chave de acesso de testdata/nfce.xml
+const sampleChave = "13240112345678000195650010000001231000001236"
+
+// readSample lê testdata/nfce.xml com a chave terminada em n, para que cada
+// XML do lote gere um arquivo diferente
+func readSample(t *testing.T, n int) []byte {
+	t.Helper()
+	xmlContent, err := os.ReadFile("testdata/nfce.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.ReplaceAll(xmlContent, []byte(sampleChave), []byte(fmt.Sprintf("%s%04d", sampleChave[:40], n)))
+}
+
+// blockingConverter espera o cancelamento do contexto em cada conversão
+type blockingConverter struct {
+	started chan struct{}
+}
+
+// Convert implementa converter.Converter
+func (c *blockingConverter) Convert(ctx context.Context, htmlContent []byte, page converter.PageSettings) ([]byte, error) {
+	c.started <- struct{}{}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestGenerateBatchZIP(t *testing.T) {
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	for name, data := range map[string][]byte{
+		"notas/b.xml":    readSample(t, 2),
+		"notas/a.XML":    readSample(t, 1),
+		"notas/leia.txt": []byte("não é XML"),
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	if _, err := zw.Create("notas/vazio/"); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	source := writeBatchInputs(t, map[string][]byte{"lote.zip": zipped.Bytes()})
+	outputDir := t.TempDir()
+
+	report, err := GenerateBatch(context.Background(), filepath.Join(source, "lote.zip"), BatchOptions{
+		OutputDir:   outputDir,
+		NamePattern: "{arquivo}-{numero}.{ext}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Results) != 2 {
+		t.Fatalf("esperados 2 XMLs do ZIP, obtidos %d", len(report.Results))
+	}
+	for i, want := range []string{"notas/a.XML", "notas/b.xml"} {
+		res := report.Results[i]
+		if res.Source != want || res.Status != BatchSucceeded {
+			t.Errorf("resultado %d: %s %v (%v), esperado %s", i, res.Source, res.Status, res.Err, want)
+		}
+	}
+	if files := listFiles(t, outputDir); strings.Join(files, ",") != "a-123.html,b-123.html" {
+		t.Errorf("arquivos gerados: %v", files)
+	}
+}
+
+func TestGenerateBatchProgress(t *testing.T) {
+	files := map[string][]byte{}
+	for i := 0; i < 8; i++ {
+		files[fmt.Sprintf("nota%d.xml", i)] = readSample(t, i)
+	}
+	source := writeBatchInputs(t, files)
+
+	var calls []BatchProgress
+	var running, overlap atomic.Int32
+	report, err := GenerateBatch(context.Background(), source, BatchOptions{
+		OutputDir: t.TempDir(),
+		Workers:   4,
+		Progress: func(p BatchProgress) {
+			if running.Add(1) > 1 {
+				overlap.Add(1)
+			}
+			calls = append(calls, p)
+			running.Add(-1)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overlap.Load() > 0 {
+		t.Error("Progress chamada concorrentemente")
+	}
+	if len(calls) != len(files) {
+		t.Fatalf("esperadas %d chamadas de Progress, obtidas %d", len(files), len(calls))
+	}
+	seen := map[string]bool{}
+	for i, p := range calls {
+		if p.Done != i+1 || p.Total != len(files) {
+			t.Errorf("chamada %d: Done %d, Total %d", i, p.Done, p.Total)
+		}
+		seen[p.Result.Source] = true
+	}
+	if len(seen) != len(files) {
+		t.Errorf("Progress repetiu arquivos: %v", seen)
+	}
+	// O relatório segue a ordem dos arquivos de entrada
+	for i, res := range report.Results {
+		if want := filepath.Join(source, fmt.Sprintf("nota%d.xml", i)); res.Source != want {
+			t.Errorf("resultado %d: %s, esperado %s", i, res.Source, want)
+		}
+	}
+}
+
+func TestGenerateBatchCanceled(t *testing.T) {
+	files := map[string][]byte{}
+	for i := 0; i < 10; i++ {
+		files[fmt.Sprintf("nota%02d.xml", i)] = readSample(t, i)
+	}
+	source := writeBatchInputs(t, files)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conv := &blockingConverter{started: make(chan struct{})}
+	go func() {
+		// Cancela com as duas primeiras conversões em andamento
+		<-conv.started
+		<-conv.started
+		cancel()
+		for range conv.started {
+		}
+	}()
+
+	var progress int
+	report, err := GenerateBatch(ctx, source, BatchOptions{
+		Format:           FormatPDF,
+		OutputDir:        t.TempDir(),
+		Workers:          2,
+		GeneratorOptions: []Option{WithConverter(conv)},
+		Progress:         func(BatchProgress) { progress++ },
+	})
+	close(conv.started)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("esperado context.Canceled, obtido %v", err)
+	}
+	if n := report.Count(BatchCanceled); n != len(files) {
+		for _, res := range report.Results {
+			t.Logf("%s: %v %v", res.Source, res.Status, res.Err)
+		}
+		t.Errorf("esperados %d cancelados, obtidos %d", len(files), n)
+	}
+	if progress < 2 {
+		t.Errorf("Progress chamada %d vezes, esperado ao menos para os arquivos em andamento", progress)
+	}
+}
+
+func TestGenerateBatchStatus(t *testing.T) {
+	denied := bytes.Replace(readSample(t, 3), []byte("<cStat>100</cStat>"), []byte("<cStat>110</cStat>"), 1)
+	nfe := bytes.Replace(readSample(t, 4), []byte("<mod>65</mod>"), []byte("<mod>55</mod>"), 1)
+
+	tests := []struct {
+		name    string
+		xml     []byte
+		options BatchOptions
+		want    BatchStatus
+		wantErr error
+	}{
+		{"autorizada", readSample(t, 1), BatchOptions{}, BatchSucceeded, nil},
+		{"malformado", []byte("<nfeProc><NFe>"), BatchOptions{}, BatchValidationFailed, ErrMalformedXML},
+		{"denegada", denied, BatchOptions{}, BatchValidationFailed, ErrNotAuthorized},
+		{"modelo 55", nfe, BatchOptions{}, BatchValidationFailed, ErrNotNFCe},
+		{"formato desconhecido", readSample(t, 5), BatchOptions{Format: "docx"}, BatchValidationFailed, ErrUnsupportedFormat},
+		{"erro do Gotenberg", readSample(t, 6), BatchOptions{
+			Format:           FormatPDF,
+			GeneratorOptions: []Option{WithConverter(&converter.FakeConverter{Err: &ConverterError{StatusCode: 503}})},
+		}, BatchConverterFailed, ErrConversion},
+		{"Gotenberg sem URL", readSample(t, 8), BatchOptions{
+			Format:           FormatPDF,
+			GeneratorOptions: []Option{WithConverter(converter.NewPDFConverter(converter.NewConverterConfig()))},
+		}, BatchConverterFailed, converter.ErrNoURL},
+		{"PDFHTML sem conversor", readSample(t, 9), BatchOptions{
+			Format:           FormatPDF,
+			GeneratorOptions: []Option{WithPDFBackend(PDFHTML)},
+		}, BatchConverterFailed, ErrNoConverter},
+		{"wkhtmltopdf ausente", readSample(t, 10), BatchOptions{
+			Format:           FormatPDF,
+			GeneratorOptions: []Option{WithConverter(converter.NewWkhtmltopdfConverter(filepath.Join(t.TempDir(), "wkhtmltopdf")))},
+		}, BatchConverterFailed, ErrConversion},
+		{"saída inválida", readSample(t, 7), BatchOptions{NamePattern: "../{chave}.{ext}"}, BatchFailed, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := writeBatchInputs(t, map[string][]byte{"nota.xml": tt.xml})
+			tt.options.OutputDir = t.TempDir()
+			report, err := GenerateBatch(context.Background(), source, tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res := report.Results[0]
+			if res.Status != tt.want {
+				t.Errorf("situação %v, esperada %v: %v", res.Status, tt.want, res.Err)
+			}
+			if tt.wantErr != nil && !errors.Is(res.Err, tt.wantErr) {
+				t.Errorf("erro %v, esperado %v", res.Err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,559 @@
+// Package builder monta NFC-e (xmlparser.NFeProc) consistentes em Go, para
+// testes e fixtures sem dados reais de clientes.
+package builder
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marcelo-cunha/nfce-render/xmlparser"
+)
+
+// MaxItens é o número máximo de itens de uma NF-e
+const MaxItens = 990
+
+// Namespace é o namespace do leiaute da NF-e
+const Namespace = "http://www.portalfiscal.inf.br/nfe"
+
+// Emitente contém os dados do emitente da NFC-e
+type Emitente struct {
+	CNPJ      string
+	Nome      string
+	Fantasia  string
+	IE        string
+	UF        string
+	Municipio string // nome do município; padrão: capital da UF
+	CMun      string // código IBGE do município; padrão: capital da UF
+	Endereco  string
+	Numero    string
+	Bairro    string
+	CEP       string
+}
+
+// Item contém os dados de um item vendido
+type Item struct {
+	Codigo        string
+	Descricao     string
+	GTIN          string // padrão: "SEM GTIN"
+	NCM           string
+	CFOP          string // padrão: "5102"
+	Unidade       string // padrão: "UN"
+	Quantidade    float64
+	ValorUnitario float64
+	Desconto      float64
+	AliquotaICMS  float64 // usada com CRT 3; padrão: 18%
+}
+
+// Pagamento contém uma forma de pagamento (detPag)
+type Pagamento struct {
+	TPag  string
+	Valor float64
+}
+
+// Builder monta uma NFC-e a partir de chamadas encadeadas:
+//
+//	nfe, err := builder.New().
+//		Emitente(builder.Emitente{CNPJ: "12345678000195", Nome: "LOJA", UF: "SP"}).
+//		AddItem(builder.Item{Descricao: "CAFE 500G", Quantidade: 2, ValorUnitario: 10.5}).
+//		AddPagamento("01", 50).
+//		Build()
+//
+// A chave de acesso, o dígito verificador, os totais (ICMSTot), o troco e o
+// QR Code são calculados em Build.
+type Builder struct {
+	emitente      Emitente
+	crt           string
+	serie         int
+	numero        int
+	cnf           string
+	dhEmi         time.Time
+	tpAmb         string
+	tpEmis        string
+	justificativa string
+	consumidor    *xmlparser.Dest
+	itens         []Item
+	pagamentos    []Pagamento
+	cStat         string
+	xMotivo       string
+	semProtocolo  bool
+	idToken       string
+	csc           string
+}
+
+// New cria um Builder com os valores padrão: emitente fictício em SP,
+// Simples Nacional (CRT 1), produção, emissão normal e NF-e autorizada
+func New() *Builder {
+	return &Builder{
+		emitente: Emitente{
+			CNPJ:     "11222333000181",
+			Nome:     "EMPRESA DE TESTE LTDA",
+			IE:       "111222333444",
+			UF:       "SP",
+			Endereco: "RUA DE TESTE",
+			Numero:   "100",
+			Bairro:   "CENTRO",
+		},
+		crt:     "1",
+		serie:   1,
+		numero:  1,
+		tpAmb:   "1",
+		tpEmis:  "1",
+		idToken: "000001",
+		csc:     "CSC-DE-TESTE",
+	}
+}
+
+// Emitente define o emitente da NFC-e
+func (b *Builder) Emitente(e Emitente) *Builder {
+	b.emitente = e
+	return b
+}
+
+// CRT define o código de regime tributário (1, 2 e 4: Simples Nacional; 3: regime normal)
+func (b *Builder) CRT(crt string) *Builder {
+	b.crt = crt
+	return b
+}
+
+// Serie define a série da NFC-e
+func (b *Builder) Serie(serie int) *Builder {
+	b.serie = serie
+	return b
+}
+
+// Numero define o número da NFC-e
+func (b *Builder) Numero(numero int) *Builder {
+	b.numero = numero
+	return b
+}
+
+// CodigoNumerico define o código numérico (cNF) que compõe a chave de acesso
+func (b *Builder) CodigoNumerico(cnf string) *Builder {
+	b.cnf = cnf
+	return b
+}
+
+// DataEmissao define a data de emissão. O padrão é o horário atual na UF do emitente.
+func (b *Builder) DataEmissao(t time.Time) *Builder {
+	b.dhEmi = t
+	return b
+}
+
+// Homologacao emite a NFC-e em ambiente de homologação (tpAmb 2). A descrição
+// do primeiro item e o nome do consumidor seguem as regras da SEFAZ.
+func (b *Builder) Homologacao() *Builder {
+	b.tpAmb = "2"
+	return b
+}
+
+// TpEmis define a forma de emissão (1: normal, 9: contingência offline)
+func (b *Builder) TpEmis(tpEmis string) *Builder {
+	b.tpEmis = tpEmis
+	return b
+}
+
+// ContingenciaOffline emite a NFC-e em contingência offline (tpEmis 9), sem
+// protocolo de autorização
+func (b *Builder) ContingenciaOffline(justificativa string) *Builder {
+	b.tpEmis = "9"
+	b.justificativa = justificativa
+	b.semProtocolo = true
+	return b
+}
+
+// Consumidor identifica o consumidor pelo CPF (11 dígitos) ou CNPJ (14 dígitos)
+func (b *Builder) Consumidor(documento, nome string) *Builder {
+	dest := &xmlparser.Dest{XNome: nome}
+	if len(documento) == 14 {
+		dest.CNPJ = documento
+	} else {
+		dest.CPF = documento
+	}
+	b.consumidor = dest
+	return b
+}
+
+// AddItem acrescenta um item à NFC-e
+func (b *Builder) AddItem(item Item) *Builder {
+	b.itens = append(b.itens, item)
+	return b
+}
+
+// AddPagamento acrescenta uma forma de pagamento. Sem pagamentos, Build usa
+// dinheiro (01) no valor total da nota; o valor pago acima do total vira troco.
+func (b *Builder) AddPagamento(tPag string, valor float64) *Builder {
+	b.pagamentos = append(b.pagamentos, Pagamento{TPag: tPag, Valor: valor})
+	return b
+}
+
+// Protocolo define o cStat e o xMotivo do protocolo, por exemplo 110 para
+// uma NF-e denegada. O padrão é 100 (autorizada).
+func (b *Builder) Protocolo(cStat, xMotivo string) *Builder {
+	b.cStat = cStat
+	b.xMotivo = xMotivo
+	b.semProtocolo = false
+	return b
+}
+
+// SemProtocolo gera a NFC-e sem o protocolo de autorização
+func (b *Builder) SemProtocolo() *Builder {
+	b.semProtocolo = true
+	return b
+}
+
+// CSC define o identificador e o Código de Segurança do Contribuinte usados
+// no hash do QR Code
+func (b *Builder) CSC(idToken, csc string) *Builder {
+	b.idToken = idToken
+	b.csc = csc
+	return b
+}
+
+// Build monta a NFC-e
+func (b *Builder) Build() (*xmlparser.NFeProc, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	uf := strings.ToUpper(b.emitente.UF)
+	cUF := xmlparser.CodeFromUF(uf)
+	capital := capitais[uf]
+	loc := xmlparser.LocationForUF(uf)
+
+	dhEmi := b.dhEmi
+	if dhEmi.IsZero() {
+		dhEmi = time.Now()
+	}
+	dhEmi = dhEmi.In(loc).Truncate(time.Second)
+
+	cnf := b.cnf
+	if cnf == "" {
+		cnf = fmt.Sprintf("%08d", (b.numero*7919+b.serie*104729)%100000000)
+	}
+
+	chave := xmlparser.ChaveAcesso{
+		CUF:    cUF,
+		AAMM:   dhEmi.Format("0601"),
+		CNPJ:   b.emitente.CNPJ,
+		Modelo: "65",
+		Serie:  fmt.Sprintf("%03d", b.serie),
+		Numero: fmt.Sprintf("%09d", b.numero),
+		TpEmis: b.tpEmis,
+		CNF:    cnf,
+	}
+	dv, err := xmlparser.DigitoVerificador(chave.String())
+	if err != nil {
+		return nil, err
+	}
+	chave.DV = dv
+
+	inf := xmlparser.InfNFe{
+		ID:     "NFe" + chave.String(),
+		Versao: "4.00",
+		Ide: xmlparser.Ide{
+			CUF:      cUF,
+			CNF:      cnf,
+			NatOp:    "VENDA",
+			Mod:      "65",
+			Serie:    strconv.Itoa(b.serie),
+			NNF:      strconv.Itoa(b.numero),
+			DHEmi:    dhEmi,
+			TpNF:     "1",
+			IDDest:   "1",
+			CMunFG:   firstNonEmpty(b.emitente.CMun, capital.cMun),
+			TpImp:    "4",
+			TpEmis:   b.tpEmis,
+			CDV:      dv,
+			TpAmb:    b.tpAmb,
+			FinNFe:   "1",
+			IndFinal: "1",
+			IndPres:  "1",
+			ProcEmi:  "0",
+			VerProc:  "nfce-render",
+		},
+		Emit: xmlparser.Emit{
+			CNPJ:  b.emitente.CNPJ,
+			XNome: b.emitente.Nome,
+			XFant: b.emitente.Fantasia,
+			EnderEmit: xmlparser.EnderEmit{
+				XLgr:    b.emitente.Endereco,
+				Nro:     b.emitente.Numero,
+				XBairro: b.emitente.Bairro,
+				CMun:    firstNonEmpty(b.emitente.CMun, capital.cMun),
+				XMun:    firstNonEmpty(b.emitente.Municipio, capital.nome),
+				UF:      uf,
+				CEP:     firstNonEmpty(b.emitente.CEP, capital.cep),
+				CPais:   "1058",
+				XPais:   "BRASIL",
+			},
+			IE:  b.emitente.IE,
+			CRT: b.crt,
+		},
+		Transp: &xmlparser.Transp{ModFrete: "9"},
+	}
+
+	if b.tpEmis != "1" {
+		justificativa := b.justificativa
+		if justificativa == "" {
+			justificativa = "Falha de comunicacao com o servidor da SEFAZ"
+		}
+		inf.Ide.DHCont = &dhEmi
+		inf.Ide.XJust = justificativa
+	}
+
+	if b.consumidor != nil {
+		dest := *b.consumidor
+		inf.Dest = &dest
+	}
+
+	for i, item := range b.itens {
+		inf.Det = append(inf.Det, b.det(i, item))
+	}
+	inf.Total.ICMSTot = totals(inf.Det)
+
+	pag, err := b.pag(inf.Total.ICMSTot.VNF)
+	if err != nil {
+		return nil, err
+	}
+	inf.Pag = pag
+
+	if b.tpAmb == "2" {
+		inf.Det[0].Prod.XProd = xmlparser.XProdHomologacao
+		if inf.Dest != nil {
+			inf.Dest.XNome = xmlparser.XNomeHomologacao
+		}
+	}
+
+	nfe := &xmlparser.NFeProc{
+		Versao: "4.00",
+		Xmlns:  Namespace,
+		NFe: xmlparser.NFe{
+			InfNFe:     inf,
+			InfNFeSupl: b.supl(chave.String(), uf, dhEmi, inf.Total.ICMSTot.VNF),
+		},
+	}
+
+	if !b.semProtocolo {
+		nfe.ProtNFe = b.prot(chave.String(), cUF, dhEmi)
+	}
+
+	return nfe, nil
+}
+
+// BuildXML monta a NFC-e e a escreve como XML
+func (b *Builder) BuildXML() ([]byte, error) {
+	nfe, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return nfe.Marshal(xmlparser.MarshalOptions{Header: true})
+}
+
+// validate verifica os dados obrigatórios
+func (b *Builder) validate() error {
+	if _, ok := capitais[strings.ToUpper(b.emitente.UF)]; !ok {
+		return fmt.Errorf("UF do emitente inválida: %q", b.emitente.UF)
+	}
+	if len(b.emitente.CNPJ) != 14 {
+		return fmt.Errorf("CNPJ do emitente deve ter 14 dígitos: %q", b.emitente.CNPJ)
+	}
+	if len(b.itens) == 0 {
+		return fmt.Errorf("a NFC-e deve ter ao menos um item")
+	}
+	if len(b.itens) > MaxItens {
+		return fmt.Errorf("a NFC-e deve ter no máximo %d itens: %d", MaxItens, len(b.itens))
+	}
+	if b.serie < 0 || b.serie > 999 {
+		return fmt.Errorf("série inválida: %d", b.serie)
+	}
+	if b.numero < 1 || b.numero > 999999999 {
+		return fmt.Errorf("número inválido: %d", b.numero)
+	}
+	if b.cnf != "" && len(b.cnf) != 8 {
+		return fmt.Errorf("código numérico deve ter 8 dígitos: %q", b.cnf)
+	}
+	if len(b.tpEmis) != 1 {
+		return fmt.Errorf("forma de emissão inválida: %q", b.tpEmis)
+	}
+	return nil
+}
+
+// det monta o item com os impostos do regime tributário
+func (b *Builder) det(index int, item Item) xmlparser.Det {
+	vProd := round(item.Quantidade * item.ValorUnitario)
+	gtin := firstNonEmpty(item.GTIN, "SEM GTIN")
+	unidade := firstNonEmpty(item.Unidade, "UN")
+	codigo := firstNonEmpty(item.Codigo, fmt.Sprintf("%03d", index+1))
+
+	det := xmlparser.Det{
+		NItem: strconv.Itoa(index + 1),
+		Prod: xmlparser.Prod{
+			CProd:    codigo,
+			CEAN:     gtin,
+			XProd:    item.Descricao,
+			NCM:      firstNonEmpty(item.NCM, "21069090"),
+			CFOP:     firstNonEmpty(item.CFOP, "5102"),
+			UCom:     unidade,
+			QCom:     item.Quantidade,
+			VUnCom:   item.ValorUnitario,
+			VProd:    vProd,
+			CEANTrib: gtin,
+			UTrib:    unidade,
+			QTrib:    item.Quantidade,
+			VUnTrib:  item.ValorUnitario,
+			VDesc:    round(item.Desconto),
+			IndTot:   "1",
+		},
+	}
+
+	if b.crt == "3" {
+		aliquota := item.AliquotaICMS
+		if aliquota == 0 {
+			aliquota = 18
+		}
+		vBC := round(vProd - item.Desconto)
+		det.Imposto = xmlparser.Imposto{
+			ICMS: &xmlparser.ICMS{ICMS00: &xmlparser.ICMS00{
+				Orig: "0", CST: "00", ModBC: "3",
+				VBC: vBC, PICMS: aliquota, VICMS: round(vBC * aliquota / 100),
+			}},
+			PIS: &xmlparser.PIS{PISAliq: &xmlparser.PISAliq{
+				CST: "01", VBC: vBC, PPIS: 1.65, VPIS: round(vBC * 1.65 / 100),
+			}},
+			COFINS: &xmlparser.COFINS{COFINSAliq: &xmlparser.COFINSAliq{
+				CST: "01", VBC: vBC, PCOFINS: 7.6, VCOFINS: round(vBC * 7.6 / 100),
+			}},
+		}
+	} else {
+		det.Imposto = xmlparser.Imposto{
+			ICMS:   &xmlparser.ICMS{ICMSSN102: &xmlparser.ICMSSN102{Orig: "0", CSOSN: "102"}},
+			PIS:    &xmlparser.PIS{PISNT: &xmlparser.PISNT{CST: "07"}},
+			COFINS: &xmlparser.COFINS{COFINSNT: &xmlparser.COFINSNT{CST: "07"}},
+		}
+	}
+
+	return det
+}
+
+// totals soma os valores dos itens no grupo ICMSTot
+func totals(dets []xmlparser.Det) xmlparser.ICMSTot {
+	var tot xmlparser.ICMSTot
+	for _, det := range dets {
+		tot.VProd += det.Prod.VProd
+		tot.VDesc += det.Prod.VDesc
+		if icms := det.Imposto.ICMS; icms != nil && icms.ICMS00 != nil {
+			tot.VBC += icms.ICMS00.VBC
+			tot.VICMS += icms.ICMS00.VICMS
+		}
+		if pis := det.Imposto.PIS; pis != nil && pis.PISAliq != nil {
+			tot.VPIS += pis.PISAliq.VPIS
+		}
+		if cofins := det.Imposto.COFINS; cofins != nil && cofins.COFINSAliq != nil {
+			tot.VCOFINS += cofins.COFINSAliq.VCOFINS
+		}
+	}
+
+	tot.VProd = round(tot.VProd)
+	tot.VDesc = round(tot.VDesc)
+	tot.VBC = round(tot.VBC)
+	tot.VICMS = round(tot.VICMS)
+	tot.VPIS = round(tot.VPIS)
+	tot.VCOFINS = round(tot.VCOFINS)
+	tot.VNF = round(tot.VProd - tot.VDesc)
+	return tot
+}
+
+// pag monta o grupo de pagamento, calculando o troco
+func (b *Builder) pag(vNF float64) (xmlparser.Pag, error) {
+	pagamentos := b.pagamentos
+	if len(pagamentos) == 0 {
+		pagamentos = []Pagamento{{TPag: "01", Valor: vNF}}
+	}
+
+	var pag xmlparser.Pag
+	var total float64
+	for _, p := range pagamentos {
+		detPag := xmlparser.DetPag{TPag: p.TPag, VPag: round(p.Valor)}
+		switch p.TPag {
+		case "03", "04", "17":
+			detPag.Card = &xmlparser.Card{TpIntegra: "2"}
+		}
+		pag.DetPag = append(pag.DetPag, detPag)
+		total += detPag.VPag
+	}
+
+	total = round(total)
+	if total < vNF {
+		return pag, fmt.Errorf("pagamentos (%.2f) menores que o valor da nota (%.2f)", total, vNF)
+	}
+	pag.VTroco = round(total - vNF)
+	return pag, nil
+}
+
+// supl monta o QR Code versão 2 e a URL de consulta
+func (b *Builder) supl(chave, uf string, dhEmi time.Time, vNF float64) *xmlparser.InfNFeSupl {
+	host := "www.sefaz." + strings.ToLower(uf) + ".gov.br"
+
+	var params string
+	if b.tpEmis == "9" {
+		digVal := sha1.Sum([]byte(chave))
+		params = strings.Join([]string{
+			chave, "2", b.tpAmb, dhEmi.Format("02"), fmt.Sprintf("%.2f", vNF),
+			hex.EncodeToString([]byte(base64.StdEncoding.EncodeToString(digVal[:]))),
+			strings.TrimLeft(b.idToken, "0"),
+		}, "|")
+	} else {
+		params = strings.Join([]string{chave, "2", b.tpAmb, strings.TrimLeft(b.idToken, "0")}, "|")
+	}
+	hash := sha1.Sum([]byte(params + b.csc))
+	params += "|" + strings.ToUpper(hex.EncodeToString(hash[:]))
+
+	return &xmlparser.InfNFeSupl{
+		QrCode:   "https://" + host + "/nfce/qrcode?p=" + params,
+		UrlChave: host + "/nfce/consulta",
+	}
+}
+
+// prot monta o protocolo de autorização
+func (b *Builder) prot(chave, cUF string, dhEmi time.Time) xmlparser.ProtNFe {
+	cStat, xMotivo := b.cStat, b.xMotivo
+	if cStat == "" {
+		cStat, xMotivo = "100", "Autorizado o uso da NF-e"
+	}
+
+	digVal := sha1.Sum([]byte(chave))
+	return xmlparser.ProtNFe{
+		Versao: "4.00",
+		InfProt: xmlparser.InfProt{
+			TpAmb:    b.tpAmb,
+			VerAplic: "SVRS202401011200",
+			ChNFe:    chave,
+			DhRecbto: dhEmi.Add(2 * time.Second),
+			NProt:    "1" + cUF + dhEmi.Format("06") + chave[25:34] + chave[43:],
+			DigVal:   base64.StdEncoding.EncodeToString(digVal[:]),
+			CStat:    cStat,
+			XMotivo:  xMotivo,
+		},
+	}
+}
+
+// round arredonda valores monetários para 2 casas decimais
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// firstNonEmpty retorna o primeiro valor não vazio
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
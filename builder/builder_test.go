@@ -0,0 +1,176 @@
+package builder
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcelo-cunha/nfce-render/renderer"
+	"github.com/marcelo-cunha/nfce-render/xmlparser"
+)
+
+func TestDigitoVerificador(t *testing.T) {
+	// Exemplo do Manual de Orientação do Contribuinte
+	chave := "52060433009911002506550120000007800267301615"
+	dv, err := xmlparser.DigitoVerificador(chave[:43])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dv != chave[43:] {
+		t.Errorf("dígito verificador %s, esperado %s", dv, chave[43:])
+	}
+	if _, err := xmlparser.DigitoVerificador(chave); err == nil {
+		t.Error("chave com 44 dígitos aceita")
+	}
+}
+
+// withItems cria um Builder com dois itens de totais conhecidos
+func withItems(crt string) *Builder {
+	return New().
+		CRT(crt).
+		AddItem(Item{Descricao: "CAFE 500G", Quantidade: 2, ValorUnitario: 10.50, Desconto: 1}).
+		AddItem(Item{Descricao: "ACUCAR 1KG", Quantidade: 3, ValorUnitario: 4.99, AliquotaICMS: 12}).
+		AddPagamento("01", 20).
+		AddPagamento("03", 20)
+}
+
+func TestTotals(t *testing.T) {
+	tests := []struct {
+		crt  string
+		want xmlparser.ICMSTot
+	}{
+		{"1", xmlparser.ICMSTot{VProd: 35.97, VDesc: 1, VNF: 34.97}},
+		{"3", xmlparser.ICMSTot{VProd: 35.97, VDesc: 1, VBC: 34.97, VICMS: 5.40, VPIS: 0.58, VCOFINS: 2.66, VNF: 34.97}},
+	}
+	for _, tt := range tests {
+		t.Run("CRT "+tt.crt, func(t *testing.T) {
+			nfe, err := withItems(tt.crt).Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			inf := nfe.NFe.InfNFe
+			if got := inf.Total.ICMSTot; got != tt.want {
+				t.Errorf("totais %+v, esperados %+v", got, tt.want)
+			}
+			if inf.Pag.VTroco != 5.03 {
+				t.Errorf("troco %.2f, esperado 5.03", inf.Pag.VTroco)
+			}
+			if inf.Emit.CRT != tt.crt {
+				t.Errorf("CRT %s, esperado %s", inf.Emit.CRT, tt.crt)
+			}
+		})
+	}
+}
+
+func TestPagamentoMenorQueTotal(t *testing.T) {
+	_, err := New().
+		AddItem(Item{Descricao: "CAFE 500G", Quantidade: 1, ValorUnitario: 10}).
+		AddPagamento("01", 9.99).
+		Build()
+	if err == nil || !strings.Contains(err.Error(), "pagamentos (9.99) menores que o valor da nota (10.00)") {
+		t.Errorf("esperado erro de pagamento insuficiente, obtido %v", err)
+	}
+}
+
+func TestRandomDeterministic(t *testing.T) {
+	first, err := RandomXML(42, RandomOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := RandomXML(42, RandomOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Error("mesma seed gerou documentos diferentes")
+	}
+	other, err := RandomXML(43, RandomOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first, other) {
+		t.Error("seeds diferentes geraram o mesmo documento")
+	}
+}
+
+func TestBuildRoundTrip(t *testing.T) {
+	dhEmi := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	items := func(n int) *Builder {
+		b := New().DataEmissao(dhEmi)
+		for i := 0; i < n; i++ {
+			b.AddItem(Item{Descricao: "PRODUTO", Quantidade: 1, ValorUnitario: 1.25})
+		}
+		return b
+	}
+
+	tests := []struct {
+		name    string
+		builder *Builder
+		check   func(t *testing.T, nfe *xmlparser.NFeProc, html string)
+	}{
+		{"1 item", items(1), func(t *testing.T, nfe *xmlparser.NFeProc, html string) {
+			if n := len(nfe.NFe.InfNFe.Det); n != 1 {
+				t.Errorf("%d itens, esperado 1", n)
+			}
+			if status := nfe.GetStatus(); status != xmlparser.StatusAuthorized {
+				t.Errorf("situação %s, esperada autorizada", status)
+			}
+		}},
+		{"990 itens", items(MaxItens), func(t *testing.T, nfe *xmlparser.NFeProc, html string) {
+			if n := len(nfe.NFe.InfNFe.Det); n != MaxItens {
+				t.Errorf("%d itens, esperados %d", n, MaxItens)
+			}
+			if vNF := nfe.NFe.InfNFe.Total.ICMSTot.VNF; vNF != 1237.50 {
+				t.Errorf("vNF %.2f, esperado 1237.50", vNF)
+			}
+		}},
+		{"homologação", items(1).Homologacao().Consumidor("12345678909", "FULANO"), func(t *testing.T, nfe *xmlparser.NFeProc, html string) {
+			inf := nfe.NFe.InfNFe
+			if inf.Ide.TpAmb != "2" || inf.Det[0].Prod.XProd != xmlparser.XProdHomologacao || inf.Dest.XNome != xmlparser.XNomeHomologacao {
+				t.Errorf("textos de homologação ausentes: tpAmb %s, xProd %q", inf.Ide.TpAmb, inf.Det[0].Prod.XProd)
+			}
+			if !strings.Contains(html, xmlparser.MensagemHomologacao) {
+				t.Error("aviso de homologação ausente no DANFE")
+			}
+		}},
+		{"contingência offline", items(1).ContingenciaOffline(""), func(t *testing.T, nfe *xmlparser.NFeProc, html string) {
+			if !nfe.IsContingenciaOffline() {
+				t.Error("NFC-e fora de contingência offline")
+			}
+			if nfe.NFe.InfNFe.Ide.DHCont == nil || nfe.NFe.InfNFe.Ide.XJust == "" {
+				t.Error("dhCont e xJust ausentes")
+			}
+			if !strings.Contains(html, "Entrada em contingência") {
+				t.Error("entrada em contingência ausente no DANFE")
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.builder.BuildXML()
+			if err != nil {
+				t.Fatal(err)
+			}
+			nfe, err := xmlparser.ParseXML(data)
+			if err != nil {
+				t.Fatalf("XML gerado inválido: %v", err)
+			}
+			chave := strings.TrimPrefix(nfe.NFe.InfNFe.ID, "NFe")
+			if dv, _ := xmlparser.DigitoVerificador(chave[:43]); dv != chave[43:] || dv != nfe.NFe.InfNFe.Ide.CDV {
+				t.Errorf("dígito verificador inválido na chave %s", chave)
+			}
+
+			var buf bytes.Buffer
+			if err := renderer.NewHTMLRenderer(nfe).RenderToWriter(&buf); err != nil {
+				t.Fatalf("erro ao renderizar: %v", err)
+			}
+			tt.check(t, nfe, buf.String())
+		})
+	}
+
+	if _, err := items(MaxItens + 1).Build(); err == nil {
+		t.Errorf("NFC-e com %d itens aceita", MaxItens+1)
+	}
+}
@@ -0,0 +1,158 @@
+package builder
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/marcelo-cunha/nfce-render/xmlparser"
+)
+
+// RandomOptions restringe os valores sorteados por Random. Campos vazios são sorteados.
+type RandomOptions struct {
+	UF          string
+	CRT         string
+	TpEmis      string // 1 (normal) ou 9 (contingência offline); padrão: 1
+	Homologacao bool
+	Itens       int // padrão: entre 1 e 30
+}
+
+// Random cria um Builder com uma NFC-e fictícia e realista. A mesma seed
+// gera sempre o mesmo documento. O Builder pode ser ajustado antes de Build.
+func Random(seed int64, opts RandomOptions) *Builder {
+	r := rand.New(rand.NewSource(seed))
+
+	uf := opts.UF
+	if uf == "" {
+		ufs := make([]string, 0, len(capitais))
+		for sigla := range capitais {
+			ufs = append(ufs, sigla)
+		}
+		sort.Strings(ufs)
+		uf = ufs[r.Intn(len(ufs))]
+	}
+
+	crt := opts.CRT
+	if crt == "" {
+		crt = []string{"1", "1", "3", "4"}[r.Intn(4)]
+	}
+
+	b := New().
+		Emitente(Emitente{
+			CNPJ:     randomCNPJ(r),
+			Nome:     fmt.Sprintf("%s %s COMERCIO LTDA", sobrenomes[r.Intn(len(sobrenomes))], sobrenomes[r.Intn(len(sobrenomes))]),
+			Fantasia: "MERCADO " + sobrenomes[r.Intn(len(sobrenomes))],
+			IE:       randomDigits(r, 12),
+			UF:       uf,
+			Endereco: "RUA " + nomes[r.Intn(len(nomes))] + " " + sobrenomes[r.Intn(len(sobrenomes))],
+			Numero:   fmt.Sprint(1 + r.Intn(2000)),
+			Bairro:   "CENTRO",
+		}).
+		CRT(crt).
+		Serie(1 + r.Intn(9)).
+		Numero(1 + r.Intn(999999)).
+		CodigoNumerico(randomDigits(r, 8)).
+		DataEmissao(randomDate(r, xmlparser.LocationForUF(uf)))
+
+	if opts.Homologacao {
+		b.Homologacao()
+	}
+	if opts.TpEmis != "" && opts.TpEmis != "1" {
+		b.ContingenciaOffline("")
+		b.TpEmis(opts.TpEmis)
+	}
+
+	if r.Intn(3) == 0 {
+		b.Consumidor(randomCPF(r), nomes[r.Intn(len(nomes))]+" "+sobrenomes[r.Intn(len(sobrenomes))])
+	}
+
+	itens := opts.Itens
+	if itens <= 0 {
+		itens = 1 + r.Intn(30)
+	}
+	var total float64
+	for i := 0; i < itens; i++ {
+		p := catalogo[r.Intn(len(catalogo))]
+		quantidade := float64(1 + r.Intn(4))
+		if p.fracao {
+			quantidade = math.Round((0.1+r.Float64()*2)*1000) / 1000
+		}
+		item := Item{
+			Codigo:        fmt.Sprintf("%06d", r.Intn(1000000)),
+			Descricao:     p.descricao,
+			NCM:           p.ncm,
+			Unidade:       p.unidade,
+			Quantidade:    quantidade,
+			ValorUnitario: p.preco,
+		}
+		if r.Intn(10) == 0 {
+			item.Desconto = round(quantidade * p.preco * 0.1)
+		}
+		b.AddItem(item)
+		total += round(quantidade*p.preco) - item.Desconto
+	}
+
+	total = round(total)
+	switch r.Intn(3) {
+	case 0:
+		// Dinheiro com troco
+		b.AddPagamento("01", math.Ceil(total/10)*10)
+	case 1:
+		b.AddPagamento(meiosPagamento[1+r.Intn(len(meiosPagamento)-1)], total)
+	default:
+		parcial := round(total * r.Float64())
+		b.AddPagamento("17", parcial)
+		b.AddPagamento("04", round(total-parcial))
+	}
+
+	return b
+}
+
+// RandomXML gera o XML de uma NFC-e fictícia a partir da seed
+func RandomXML(seed int64, opts RandomOptions) ([]byte, error) {
+	return Random(seed, opts).BuildXML()
+}
+
+// randomDate sorteia uma data de emissão em horário comercial
+func randomDate(r *rand.Rand, loc *time.Location) time.Time {
+	base := time.Date(2024, time.January, 1, 8, 0, 0, 0, loc)
+	return base.AddDate(0, 0, r.Intn(365)).Add(time.Duration(r.Intn(12*60*60)) * time.Second)
+}
+
+// randomDigits sorteia uma sequência de n dígitos
+func randomDigits(r *rand.Rand, n int) string {
+	digits := make([]byte, n)
+	for i := range digits {
+		digits[i] = byte('0' + r.Intn(10))
+	}
+	return string(digits)
+}
+
+// randomCNPJ sorteia um CNPJ com dígitos verificadores válidos
+func randomCNPJ(r *rand.Rand) string {
+	base := randomDigits(r, 8) + "0001"
+	base += checkDigit(base, []int{5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2})
+	return base + checkDigit(base, []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2})
+}
+
+// randomCPF sorteia um CPF com dígitos verificadores válidos
+func randomCPF(r *rand.Rand) string {
+	base := randomDigits(r, 9)
+	base += checkDigit(base, []int{10, 9, 8, 7, 6, 5, 4, 3, 2})
+	return base + checkDigit(base, []int{11, 10, 9, 8, 7, 6, 5, 4, 3, 2})
+}
+
+// checkDigit calcula um dígito verificador módulo 11 de CPF e CNPJ
+func checkDigit(digits string, weights []int) string {
+	sum := 0
+	for i, w := range weights {
+		sum += int(digits[i]-'0') * w
+	}
+	dv := 11 - sum%11
+	if dv >= 10 {
+		dv = 0
+	}
+	return fmt.Sprint(dv)
+}
@@ -0,0 +1,84 @@
+package builder
+
+// capital contém os dados da capital usados como endereço padrão do emitente
+type capital struct {
+	nome string
+	cMun string
+	cep  string
+}
+
+// capitais relaciona cada UF com a sua capital
+var capitais = map[string]capital{
+	"RO": {"PORTO VELHO", "1100205", "76801000"},
+	"AC": {"RIO BRANCO", "1200401", "69900000"},
+	"AM": {"MANAUS", "1302603", "69000000"},
+	"RR": {"BOA VISTA", "1400100", "69300000"},
+	"PA": {"BELEM", "1501402", "66000000"},
+	"AP": {"MACAPA", "1600303", "68900000"},
+	"TO": {"PALMAS", "1721000", "77000000"},
+	"MA": {"SAO LUIS", "2111300", "65000000"},
+	"PI": {"TERESINA", "2211001", "64000000"},
+	"CE": {"FORTALEZA", "2304400", "60000000"},
+	"RN": {"NATAL", "2408102", "59000000"},
+	"PB": {"JOAO PESSOA", "2507507", "58000000"},
+	"PE": {"RECIFE", "2611606", "50000000"},
+	"AL": {"MACEIO", "2704302", "57000000"},
+	"SE": {"ARACAJU", "2800308", "49000000"},
+	"BA": {"SALVADOR", "2927408", "40000000"},
+	"MG": {"BELO HORIZONTE", "3106200", "30000000"},
+	"ES": {"VITORIA", "3205309", "29000000"},
+	"RJ": {"RIO DE JANEIRO", "3304557", "20000000"},
+	"SP": {"SAO PAULO", "3550308", "01000000"},
+	"PR": {"CURITIBA", "4106902", "80000000"},
+	"SC": {"FLORIANOPOLIS", "4205407", "88000000"},
+	"RS": {"PORTO ALEGRE", "4314902", "90000000"},
+	"MS": {"CAMPO GRANDE", "5002704", "79000000"},
+	"MT": {"CUIABA", "5103403", "78000000"},
+	"GO": {"GOIANIA", "5208707", "74000000"},
+	"DF": {"BRASILIA", "5300108", "70000000"},
+}
+
+// produto é um item do catálogo usado pelo gerador aleatório
+type produto struct {
+	descricao string
+	ncm       string
+	unidade   string
+	preco     float64
+	fracao    bool // vendido por peso
+}
+
+// catalogo contém produtos típicos de varejo
+var catalogo = []produto{
+	{"CAFE TORRADO E MOIDO 500G", "09012100", "UN", 18.90, false},
+	{"ARROZ BRANCO TIPO 1 5KG", "10063021", "UN", 27.49, false},
+	{"FEIJAO CARIOCA 1KG", "07133399", "UN", 8.79, false},
+	{"ACUCAR REFINADO 1KG", "17019900", "UN", 4.99, false},
+	{"OLEO DE SOJA 900ML", "15079011", "UN", 7.29, false},
+	{"LEITE UHT INTEGRAL 1L", "04012010", "UN", 5.49, false},
+	{"MACARRAO ESPAGUETE 500G", "19021900", "UN", 4.39, false},
+	{"BISCOITO RECHEADO 140G", "19053100", "UN", 3.19, false},
+	{"REFRIGERANTE COLA 2L", "22021000", "UN", 9.99, false},
+	{"AGUA MINERAL 500ML", "22011000", "UN", 2.50, false},
+	{"CERVEJA LATA 350ML", "22030000", "UN", 3.89, false},
+	{"SABAO EM PO 1KG", "34022000", "UN", 14.90, false},
+	{"DETERGENTE LIQUIDO 500ML", "34022000", "UN", 2.79, false},
+	{"PAPEL HIGIENICO 12 ROLOS", "48181000", "PCT", 21.90, false},
+	{"CREME DENTAL 90G", "33061000", "UN", 4.49, false},
+	{"SABONETE 85G", "34011190", "UN", 2.29, false},
+	{"PAO FRANCES", "19059090", "KG", 16.90, true},
+	{"BANANA PRATA", "08039000", "KG", 6.98, true},
+	{"TOMATE", "07020000", "KG", 8.49, true},
+	{"QUEIJO MUSSARELA FATIADO", "04069010", "KG", 49.90, true},
+	{"PRESUNTO COZIDO FATIADO", "16024900", "KG", 39.90, true},
+	{"CARNE BOVINA PATINHO", "02013000", "KG", 44.90, true},
+	{"FRANGO INTEIRO CONGELADO", "02071200", "KG", 12.99, true},
+}
+
+// nomes e sobrenomes usados para consumidores fictícios
+var (
+	nomes      = []string{"ANA", "BRUNO", "CARLA", "DIEGO", "ELISA", "FABIO", "GABRIELA", "HUGO", "ISABEL", "JOAO"}
+	sobrenomes = []string{"SILVA", "SANTOS", "OLIVEIRA", "SOUZA", "LIMA", "PEREIRA", "COSTA", "ALMEIDA"}
+)
+
+// meiosPagamento são os tPag usados pelo gerador aleatório
+var meiosPagamento = []string{"01", "03", "04", "17"}
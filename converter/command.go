@@ -0,0 +1,149 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CommandConverter converte HTML em PDF executando um programa local, como
+// wkhtmltopdf ou Chromium. O HTML é gravado em um diretório temporário,
+// removido ao fim da conversão. Os metadados de Document são ignorados.
+type CommandConverter struct {
+	// Path é o executável, procurado no PATH quando não é um caminho
+	Path string
+	// Args retorna os argumentos do programa para os arquivos de entrada e
+	// saída e a página informada
+	Args func(input, output string, page PageSettings) []string
+	// Prepare, quando definido, ajusta o HTML à página antes de gravá-lo,
+	// para programas que não recebem o tamanho da página por argumento
+	Prepare func(htmlContent []byte, page PageSettings) []byte
+}
+
+// NewWkhtmltopdfConverter cria um conversor que usa o wkhtmltopdf. Sem path,
+// o executável "wkhtmltopdf" é procurado no PATH. O wkhtmltopdf não gera
+// página única: sem altura, o PDF é dividido em páginas de 297mm.
+func NewWkhtmltopdfConverter(path string) *CommandConverter {
+	if path == "" {
+		path = "wkhtmltopdf"
+	}
+	return &CommandConverter{Path: path, Args: wkhtmltopdfArgs}
+}
+
+// NewChromiumConverter cria um conversor que usa o Chromium em modo headless
+// (--print-to-pdf). Sem path, o executável "chromium" é procurado no PATH.
+// O Chromium não aceita o tamanho da página por argumento: uma regra @page
+// com a página informada é acrescentada ao HTML e prevalece sobre a do
+// template. O Chromium não gera página única: sem altura, o PDF é dividido
+// em páginas de 297mm.
+func NewChromiumConverter(path string) *CommandConverter {
+	if path == "" {
+		path = "chromium"
+	}
+	return &CommandConverter{Path: path, Args: chromiumArgs, Prepare: withPageStyle}
+}
+
+// Convert implementa Converter executando o programa. O cancelamento de ctx
+// encerra o processo.
+func (c *CommandConverter) Convert(ctx context.Context, htmlContent []byte, page PageSettings) ([]byte, error) {
+	if page.Document.PDFA != "" || len(page.Document.Attachments) > 0 {
+		return nil, fmt.Errorf("%s não gera PDF/A nem embute arquivos", filepath.Base(c.Path))
+	}
+
+	dir, err := os.MkdirTemp("", "nfce-pdf-")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar diretório temporário: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "index.html")
+	output := filepath.Join(dir, "output.pdf")
+	if c.Prepare != nil {
+		htmlContent = c.Prepare(htmlContent, page)
+	}
+	if err := os.WriteFile(input, htmlContent, 0600); err != nil {
+		return nil, fmt.Errorf("erro ao escrever HTML: %w", err)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.Path, c.Args(input, output, page)...)
+	cmd.Stderr = &stderr
+	// Processos filhos podem manter stderr aberto depois do cancelamento
+	cmd.WaitDelay = time.Second
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("erro ao executar %s: %w: %s", filepath.Base(c.Path), err, strings.TrimSpace(stderr.String()))
+	}
+
+	pdfBytes, err := os.ReadFile(output)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler PDF: %w", err)
+	}
+	if len(pdfBytes) == 0 {
+		return nil, fmt.Errorf("PDF gerado está vazio")
+	}
+	return pdfBytes, nil
+}
+
+// wkhtmltopdfArgs monta os argumentos do wkhtmltopdf
+func wkhtmltopdfArgs(input, output string, page PageSettings) []string {
+	height := pageHeight(page)
+	margin := fmt.Sprintf("%gmm", page.MarginMM)
+	return []string{
+		"--quiet",
+		"--encoding", "utf-8",
+		"--enable-local-file-access",
+		"--disable-smart-shrinking",
+		"--page-width", fmt.Sprintf("%gmm", page.WidthMM),
+		"--page-height", fmt.Sprintf("%gmm", height),
+		"--margin-top", margin,
+		"--margin-bottom", margin,
+		"--margin-left", margin,
+		"--margin-right", margin,
+		input, output,
+	}
+}
+
+// chromiumArgs monta os argumentos do Chromium
+func chromiumArgs(input, output string, page PageSettings) []string {
+	return []string{
+		"--headless",
+		"--disable-gpu",
+		"--no-pdf-header-footer",
+		"--print-to-pdf=" + output,
+		"file://" + input,
+	}
+}
+
+// withPageStyle acrescenta ao HTML uma regra @page com a página informada,
+// no fim do <head> para prevalecer sobre as regras do template
+func withPageStyle(htmlContent []byte, page PageSettings) []byte {
+	style := fmt.Sprintf("<style>@page { size: %gmm %gmm; margin: %gmm; }</style>", page.WidthMM, pageHeight(page), page.MarginMM)
+	i := len(htmlContent) - len("</head>")
+	for i >= 0 && !bytes.EqualFold(htmlContent[i:i+len("</head>")], []byte("</head>")) {
+		i--
+	}
+	if i < 0 {
+		return append([]byte(style), htmlContent...)
+	}
+	out := make([]byte, 0, len(htmlContent)+len(style))
+	out = append(out, htmlContent[:i]...)
+	out = append(out, style...)
+	return append(out, htmlContent[i:]...)
+}
+
+// pageHeight retorna a altura da página, com 297mm para a página sem altura
+// fixa, que os programas locais não geram
+func pageHeight(page PageSettings) float64 {
+	if page.HeightMM == 0 {
+		return 297
+	}
+	return page.HeightMM
+}
@@ -0,0 +1,48 @@
+package converter
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestWithPageStyle(t *testing.T) {
+	html := []byte(`<html><head><style>@page { size: 80mm auto; }</style></HEAD><body></body></html>`)
+	out := string(withPageStyle(html, PageSettings{WidthMM: 210, HeightMM: 297, MarginMM: 10}))
+
+	want := `<style>@page { size: 210mm 297mm; margin: 10mm; }</style></HEAD>`
+	if !strings.Contains(out, want) {
+		t.Errorf("regra @page não acrescentada ao fim do <head>:\n%s", out)
+	}
+	if i, j := strings.Index(out, "80mm auto"), strings.Index(out, "210mm 297mm"); i > j {
+		t.Error("regra da página antes da regra do template")
+	}
+
+	out = string(withPageStyle([]byte(`<p>sem head</p>`), PageRoll80mm))
+	if !strings.HasPrefix(out, `<style>@page { size: 80mm 297mm; margin: 0mm; }</style><p>`) {
+		t.Errorf("regra @page ausente no HTML sem <head>:\n%s", out)
+	}
+}
+
+func TestCommandConverterPrepare(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh não encontrado")
+	}
+
+	// O "PDF" é a cópia do HTML gravado para o programa
+	c := NewChromiumConverter(sh)
+	c.Args = func(input, output string, page PageSettings) []string {
+		return []string{"-c", `cp "$1" "$2"`, "sh", input, output}
+	}
+
+	out, err := c.Convert(context.Background(), []byte(`<html><head></head><body>DANFE</body></html>`), PageSettings{WidthMM: 58, MarginMM: 1.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<html><head><style>@page { size: 58mm 297mm; margin: 1.5mm; }</style></head><body>DANFE</body></html>`
+	if string(out) != want {
+		t.Errorf("HTML gravado:\n%s\nesperado:\n%s", out, want)
+	}
+}
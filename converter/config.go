@@ -0,0 +1,136 @@
+package converter
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"time"
+)
+
+// ErrNoURL indica que a URL do Gotenberg não foi configurada. Não há servidor
+// padrão: o DANFE contém dados do consumidor e só deve ser enviado a um
+// Gotenberg escolhido explicitamente.
+var ErrNoURL = errors.New("URL do Gotenberg não configurada")
+
+// ConverterConfig contém a configuração do cliente do Gotenberg
+type ConverterConfig struct {
+	// URL é o endereço do Gotenberg, como http://localhost:3000
+	URL string
+	// Timeout limita cada tentativa (padrão: 30s). Ignorado com HTTPClient.
+	Timeout time.Duration
+	// Page é a página usada por ConvertHTMLToPDF (padrão: PageRoll80mm)
+	Page PageSettings
+	// HTTPClient substitui o cliente HTTP padrão
+	HTTPClient *http.Client
+	// Username e Password são enviados por autenticação básica, quando definidos
+	Username string
+	Password string
+	// Headers são acrescentados a todas as requisições
+	Headers http.Header
+	// Retry é a política de novas tentativas
+	Retry RetryPolicy
+}
+
+// Option configura o ConverterConfig
+type Option func(*ConverterConfig)
+
+// RetryPolicy define as novas tentativas após respostas 429 e 5xx ou falhas
+// de conexão, como as de um Gotenberg reiniciando
+type RetryPolicy struct {
+	// MaxRetries é o número de novas tentativas; zero desativa
+	MaxRetries int
+	// MinBackoff é a espera antes da primeira nova tentativa. A espera dobra
+	// a cada tentativa, com variação aleatória de até metade do valor.
+	MinBackoff time.Duration
+	// MaxBackoff limita a espera entre tentativas
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy é a política usada quando nenhuma é informada
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	MinBackoff: 500 * time.Millisecond,
+	MaxBackoff: 10 * time.Second,
+}
+
+// NewConverterConfig cria a configuração com os valores padrão e aplica as
+// opções. A URL não tem padrão e deve ser informada com WithURL.
+func NewConverterConfig(opts ...Option) ConverterConfig {
+	config := ConverterConfig{
+		Timeout: 30 * time.Second,
+		Page:    PageRoll80mm,
+		Headers: http.Header{},
+		Retry:   DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
+
+// ConfigFromEnv cria a configuração a partir das variáveis de ambiente
+// GOTENBERG_URL, GOTENBERG_USERNAME e GOTENBERG_PASSWORD e aplica as opções
+// em seguida. O ambiente só é lido quando esta função é chamada; arquivos
+// .env não são carregados.
+func ConfigFromEnv(opts ...Option) ConverterConfig {
+	env := []Option{WithURL(os.Getenv("GOTENBERG_URL"))}
+	if username := os.Getenv("GOTENBERG_USERNAME"); username != "" {
+		env = append(env, WithBasicAuth(username, os.Getenv("GOTENBERG_PASSWORD")))
+	}
+	return NewConverterConfig(append(env, opts...)...)
+}
+
+// WithURL define o endereço do Gotenberg
+func WithURL(url string) Option {
+	return func(c *ConverterConfig) {
+		c.URL = url
+	}
+}
+
+// WithTimeout define o tempo máximo de cada tentativa
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *ConverterConfig) {
+		c.Timeout = timeout
+	}
+}
+
+// WithPageSettings define a página usada por ConvertHTMLToPDF
+func WithPageSettings(page PageSettings) Option {
+	return func(c *ConverterConfig) {
+		c.Page = page
+	}
+}
+
+// WithHTTPClient define o cliente HTTP usado nas requisições. O timeout do
+// cliente vale para cada tentativa.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *ConverterConfig) {
+		c.HTTPClient = client
+	}
+}
+
+// WithBasicAuth envia as credenciais em todas as requisições ao Gotenberg
+func WithBasicAuth(username, password string) Option {
+	return func(c *ConverterConfig) {
+		c.Username = username
+		c.Password = password
+	}
+}
+
+// WithHeader acrescenta um cabeçalho às requisições, como Gotenberg-Trace ou
+// Gotenberg-Output-Filename
+func WithHeader(key, value string) Option {
+	return func(c *ConverterConfig) {
+		if c.Headers == nil {
+			c.Headers = http.Header{}
+		}
+		c.Headers.Add(key, value)
+	}
+}
+
+// WithRetry define a política de novas tentativas
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *ConverterConfig) {
+		c.Retry = policy
+	}
+}
@@ -0,0 +1,20 @@
+package converter
+
+import "context"
+
+// Converter converte HTML em PDF. As implementações devem interromper a
+// conversão quando ctx for cancelado.
+type Converter interface {
+	Convert(ctx context.Context, html []byte, page PageSettings) ([]byte, error)
+}
+
+// PageSettings contém as configurações de página e de documento do PDF
+type PageSettings struct {
+	WidthMM  float64
+	HeightMM float64 // zero gera uma única página com a altura do conteúdo
+	MarginMM float64
+	Document Document
+}
+
+// PageRoll80mm é a página padrão: bobina térmica de 80mm sem margens
+var PageRoll80mm = PageSettings{WidthMM: 80}
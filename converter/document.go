@@ -0,0 +1,40 @@
+package converter
+
+import "time"
+
+// PDFA é o nível de conformidade PDF/A do documento
+type PDFA string
+
+const (
+	PDFA2B PDFA = "PDF/A-2b" // arquivamento, sem arquivos embutidos
+	PDFA3B PDFA = "PDF/A-3b" // arquivamento, com arquivos associados
+)
+
+// Document contém as opções do documento PDF além da página
+type Document struct {
+	// PDFA é a conformidade PDF/A; vazio gera PDF comum
+	PDFA PDFA
+	// Metadata são os metadados do documento
+	Metadata Metadata
+	// Attachments são embutidos no PDF como arquivos associados. O PDF/A-2b
+	// não admite anexos.
+	Attachments []Attachment
+}
+
+// Metadata contém os metadados do documento PDF
+type Metadata struct {
+	Title        string
+	Author       string
+	Subject      string
+	Keywords     []string
+	CreationDate time.Time
+}
+
+// Attachment é um arquivo embutido no PDF
+type Attachment struct {
+	Name        string
+	MimeType    string
+	Description string
+	Data        []byte
+	ModDate     time.Time
+}
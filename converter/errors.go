@@ -0,0 +1,19 @@
+package converter
+
+import (
+	"fmt"
+	"time"
+)
+
+// ConverterError indica que o serviço de conversão respondeu com erro
+type ConverterError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter é o valor do cabeçalho Retry-After, quando presente
+	RetryAfter time.Duration
+}
+
+// Error implementa a interface error
+func (e *ConverterError) Error() string {
+	return fmt.Sprintf("erro na API do Gotenberg (status %d): %s", e.StatusCode, e.Body)
+}
@@ -0,0 +1,51 @@
+package converter
+
+import (
+	"context"
+	"sync"
+)
+
+// FakePDF é o PDF retornado por FakeConverter quando PDF não é definido
+var FakePDF = []byte("%PDF-1.4\n%%EOF\n")
+
+// FakeCall registra uma chamada a FakeConverter.Convert
+type FakeCall struct {
+	HTML []byte
+	Page PageSettings
+}
+
+// FakeConverter é um Converter para testes: registra as chamadas e retorna
+// PDF ou Err sem converter nada. Pode ser usado por várias goroutines.
+type FakeConverter struct {
+	PDF []byte
+	Err error
+
+	mu    sync.Mutex
+	calls []FakeCall
+}
+
+// Convert implementa Converter
+func (f *FakeConverter) Convert(ctx context.Context, htmlContent []byte, page PageSettings) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, FakeCall{HTML: append([]byte(nil), htmlContent...), Page: page})
+	f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	if f.PDF == nil {
+		return FakePDF, nil
+	}
+	return f.PDF, nil
+}
+
+// Calls retorna as chamadas recebidas, na ordem
+func (f *FakeConverter) Calls() []FakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeCall(nil), f.calls...)
+}
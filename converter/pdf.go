@@ -2,43 +2,58 @@ package converter
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"mime/multipart"
+	"net"
 	"net/http"
-	"os"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
-
-	"github.com/joho/godotenv"
 )
 
 // PDFConverter é responsável pela conversão de HTML para PDF usando Gotenberg
 type PDFConverter struct {
-	gotenbergURL string
-	client       *http.Client
+	config ConverterConfig
+	client *http.Client
 }
 
-// NewPDFConverter cria uma nova instância do conversor PDF
-func NewPDFConverter() *PDFConverter {
-	// Tentar carregar .env se existir
-	_ = godotenv.Load()
-
-	// Obter URL do Gotenberg da variável de ambiente ou usar padrão
-	gotenbergURL := os.Getenv("GOTENBERG_URL")
-	if gotenbergURL == "" {
-		gotenbergURL = "https://demo.gotenberg.dev"
+// NewPDFConverter cria uma nova instância do conversor PDF com a configuração
+// informada. Sem URL, as conversões falham com ErrNoURL.
+func NewPDFConverter(config ConverterConfig) *PDFConverter {
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: config.Timeout}
 	}
-
 	return &PDFConverter{
-		gotenbergURL: gotenbergURL,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		config: config,
+		client: client,
 	}
 }
 
-// ConvertHTMLToPDF converte conteúdo HTML para PDF usando Gotenberg
+// ConvertHTMLToPDF converte conteúdo HTML para PDF usando Gotenberg, na
+// página da configuração
 func (c *PDFConverter) ConvertHTMLToPDF(htmlContent []byte) ([]byte, error) {
+	return c.ConvertHTMLToPDFPage(htmlContent, c.config.Page)
+}
+
+// ConvertHTMLToPDFPage converte conteúdo HTML para PDF usando Gotenberg com as
+// configurações de página informadas
+func (c *PDFConverter) ConvertHTMLToPDFPage(htmlContent []byte, page PageSettings) ([]byte, error) {
+	return c.Convert(context.Background(), htmlContent, page)
+}
+
+// Convert implementa Converter enviando o HTML ao Gotenberg
+func (c *PDFConverter) Convert(ctx context.Context, htmlContent []byte, page PageSettings) ([]byte, error) {
+	if c.config.URL == "" {
+		return nil, ErrNoURL
+	}
+
 	// Preparar multipart/form-data
 	var requestBody bytes.Buffer
 	writer := multipart.NewWriter(&requestBody)
@@ -52,28 +67,31 @@ func (c *PDFConverter) ConvertHTMLToPDF(htmlContent []byte) ([]byte, error) {
 		return nil, fmt.Errorf("erro ao escrever HTML: %w", err)
 	}
 
-	// Configurar dimensões da página (80mm = 3.15 polegadas)
-	if err = writer.WriteField("paperWidth", "3.15in"); err != nil {
+	// Configurar dimensões da página em polegadas (80mm = 3.15 polegadas)
+	if err = writer.WriteField("paperWidth", inches(page.WidthMM)); err != nil {
 		return nil, fmt.Errorf("erro ao definir paperWidth: %w", err)
 	}
 
-	// Usar singlePage=true para altura dinâmica baseada no conteúdo
-	if err = writer.WriteField("singlePage", "true"); err != nil {
-		return nil, fmt.Errorf("erro ao definir singlePage: %w", err)
+	if page.HeightMM > 0 {
+		if err = writer.WriteField("paperHeight", inches(page.HeightMM)); err != nil {
+			return nil, fmt.Errorf("erro ao definir paperHeight: %w", err)
+		}
+	} else {
+		// Usar singlePage=true para altura dinâmica baseada no conteúdo
+		if err = writer.WriteField("singlePage", "true"); err != nil {
+			return nil, fmt.Errorf("erro ao definir singlePage: %w", err)
+		}
 	}
 
-	// Configurar margens zeradas
-	if err = writer.WriteField("marginTop", "0"); err != nil {
-		return nil, fmt.Errorf("erro ao definir marginTop: %w", err)
+	// Configurar margens
+	margin := "0"
+	if page.MarginMM > 0 {
+		margin = inches(page.MarginMM)
 	}
-	if err = writer.WriteField("marginBottom", "0"); err != nil {
-		return nil, fmt.Errorf("erro ao definir marginBottom: %w", err)
-	}
-	if err = writer.WriteField("marginLeft", "0"); err != nil {
-		return nil, fmt.Errorf("erro ao definir marginLeft: %w", err)
-	}
-	if err = writer.WriteField("marginRight", "0"); err != nil {
-		return nil, fmt.Errorf("erro ao definir marginRight: %w", err)
+	for _, field := range []string{"marginTop", "marginBottom", "marginLeft", "marginRight"} {
+		if err = writer.WriteField(field, margin); err != nil {
+			return nil, fmt.Errorf("erro ao definir %s: %w", field, err)
+		}
 	}
 
 	// Ativar printBackground para renderizar cores e imagens de fundo
@@ -86,24 +104,84 @@ func (c *PDFConverter) ConvertHTMLToPDF(htmlContent []byte) ([]byte, error) {
 		return nil, fmt.Errorf("erro ao definir scale: %w", err)
 	}
 
+	// Conformidade PDF/A, metadados e arquivos embutidos
+	if err = writeDocument(writer, page.Document); err != nil {
+		return nil, err
+	}
+
 	// Fechar o writer
 	if err = writer.Close(); err != nil {
 		return nil, fmt.Errorf("erro ao fechar multipart writer: %w", err)
 	}
 
-	// Criar requisição HTTP
-	var req *http.Request
-	req, err = http.NewRequest("POST", c.gotenbergURL+"/forms/chromium/convert/html", &requestBody)
+	return c.post(ctx, "/forms/chromium/convert/html", writer.FormDataContentType(), requestBody.Bytes())
+}
+
+// Health consulta o endpoint /health do Gotenberg e retorna erro quando o
+// serviço ou algum de seus módulos não está disponível
+func (c *PDFConverter) Health(ctx context.Context) error {
+	if c.config.URL == "" {
+		return ErrNoURL
+	}
+	req, err := c.newRequest(ctx, http.MethodGet, "/health", nil)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao criar requisição: %w", err)
+		return err
 	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("erro ao consultar o Gotenberg: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &ConverterError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return nil
+}
 
-	// Definir Content-Type
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+// post envia o formulário ao Gotenberg e repete a requisição conforme a
+// política de novas tentativas
+func (c *PDFConverter) post(ctx context.Context, path, contentType string, body []byte) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		pdfBytes, err := c.postOnce(ctx, path, contentType, body)
+		if err == nil {
+			return pdfBytes, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if attempt >= c.config.Retry.MaxRetries || !retryable(err) {
+			if attempt > 0 {
+				return nil, fmt.Errorf("falha após %d tentativas: %w", attempt+1, err)
+			}
+			return nil, err
+		}
+
+		wait := c.config.Retry.backoff(attempt)
+		var convErr *ConverterError
+		if errors.As(err, &convErr) && convErr.RetryAfter > wait {
+			wait = convErr.RetryAfter
+			if c.config.Retry.MaxBackoff > 0 {
+				wait = min(wait, c.config.Retry.MaxBackoff)
+			}
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// postOnce faz uma única tentativa de conversão
+func (c *PDFConverter) postOnce(ctx context.Context, path, contentType string, body []byte) ([]byte, error) {
+	req, err := c.newRequest(ctx, http.MethodPost, path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
 
 	// Executar requisição
-	var resp *http.Response
-	resp, err = c.client.Do(req)
+	resp, err := c.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao executar requisição: %w", err)
 	}
@@ -112,12 +190,15 @@ func (c *PDFConverter) ConvertHTMLToPDF(htmlContent []byte) ([]byte, error) {
 	// Verificar status da resposta
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("erro na API do Gotenberg (status %d): %s", resp.StatusCode, string(body))
+		return nil, &ConverterError{
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+			RetryAfter: retryAfter(resp.Header.Get("Retry-After")),
+		}
 	}
 
 	// Ler o PDF gerado
-	var pdfBytes []byte
-	pdfBytes, err = io.ReadAll(resp.Body)
+	pdfBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao ler PDF: %w", err)
 	}
@@ -127,4 +208,122 @@ func (c *PDFConverter) ConvertHTMLToPDF(htmlContent []byte) ([]byte, error) {
 	}
 
 	return pdfBytes, nil
-}
\ No newline at end of file
+}
+
+// newRequest cria a requisição com as credenciais e os cabeçalhos configurados
+func (c *PDFConverter) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.config.URL, "/")+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+	for key, values := range c.config.Headers {
+		req.Header[key] = values
+	}
+	if c.config.Username != "" || c.config.Password != "" {
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+	return req, nil
+}
+
+// retryable indica se a falha é transitória: 429, 5xx ou erro de conexão.
+// URL malformada, esquema não suportado e falhas de certificado não mudam
+// com uma nova tentativa.
+func retryable(err error) bool {
+	var convErr *ConverterError
+	if errors.As(err, &convErr) {
+		return convErr.StatusCode == http.StatusTooManyRequests || convErr.StatusCode >= 500
+	}
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) || urlErr.Op == "parse" {
+		return false
+	}
+	// *url.Error também implementa net.Error, por isso o teste é feito no
+	// erro que ele envolve
+	var netErr net.Error
+	return errors.As(urlErr.Err, &netErr) || errors.Is(urlErr.Err, io.EOF) || errors.Is(urlErr.Err, io.ErrUnexpectedEOF)
+}
+
+// backoff retorna a espera antes da nova tentativa attempt (a partir de
+// zero): MinBackoff dobrado a cada tentativa, limitado a MaxBackoff, menos
+// uma variação aleatória de até metade do valor
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	wait := p.MinBackoff
+	for i := 0; i < attempt && wait < p.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	if wait <= 0 {
+		return 0
+	}
+	return wait - time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// retryAfter interpreta o cabeçalho Retry-After em segundos
+func retryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// sleep espera d ou até o cancelamento de ctx
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// writeDocument acrescenta ao formulário os campos pdfa, metadata e embeds
+func writeDocument(writer *multipart.Writer, doc Document) error {
+	if doc.PDFA != "" {
+		if err := writer.WriteField("pdfa", string(doc.PDFA)); err != nil {
+			return fmt.Errorf("erro ao definir pdfa: %w", err)
+		}
+	}
+
+	meta := map[string]any{}
+	for key, value := range map[string]string{"Title": doc.Metadata.Title, "Author": doc.Metadata.Author, "Subject": doc.Metadata.Subject} {
+		if value != "" {
+			meta[key] = value
+		}
+	}
+	if len(doc.Metadata.Keywords) > 0 {
+		meta["Keywords"] = doc.Metadata.Keywords
+	}
+	if !doc.Metadata.CreationDate.IsZero() {
+		meta["CreateDate"] = doc.Metadata.CreationDate.Format(time.RFC3339)
+	}
+	if len(meta) > 0 {
+		data, err := json.Marshal(meta)
+		if err != nil {
+			return fmt.Errorf("erro ao codificar metadados: %w", err)
+		}
+		if err := writer.WriteField("metadata", string(data)); err != nil {
+			return fmt.Errorf("erro ao definir metadata: %w", err)
+		}
+	}
+
+	for _, attachment := range doc.Attachments {
+		part, err := writer.CreateFormFile("embeds", attachment.Name)
+		if err != nil {
+			return fmt.Errorf("erro ao criar form file: %w", err)
+		}
+		if _, err := part.Write(attachment.Data); err != nil {
+			return fmt.Errorf("erro ao escrever anexo: %w", err)
+		}
+	}
+	return nil
+}
+
+// inches converte milímetros para o formato de polegadas aceito pelo Gotenberg
+func inches(mm float64) string {
+	return fmt.Sprintf("%.2fin", mm/25.4)
+}
@@ -0,0 +1,229 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastRetry repete sem esperas perceptíveis
+var fastRetry = RetryPolicy{MaxRetries: 3, MinBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+// newTestConverter cria um PDFConverter apontado para o servidor de teste
+func newTestConverter(url string, opts ...Option) *PDFConverter {
+	return NewPDFConverter(NewConverterConfig(append([]Option{WithURL(url), WithRetry(fastRetry)}, opts...)...))
+}
+
+// respondSequence responde com os status informados, um por requisição, e
+// com um PDF depois do último
+func respondSequence(calls *atomic.Int32, statuses ...int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1)) - 1
+		if n < len(statuses) {
+			if statuses[n] == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "1")
+			}
+			http.Error(w, http.StatusText(statuses[n]), statuses[n])
+			return
+		}
+		w.Write(FakePDF)
+	}
+}
+
+func TestConvertRetriesAfterServiceUnavailable(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(respondSequence(&calls, http.StatusServiceUnavailable))
+	defer server.Close()
+
+	pdf, err := newTestConverter(server.URL).Convert(context.Background(), []byte("<html></html>"), PageRoll80mm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(pdf) != string(FakePDF) {
+		t.Errorf("PDF inesperado: %q", pdf)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("esperadas 2 requisições, obtidas %d", n)
+	}
+}
+
+func TestConvertHonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(respondSequence(&calls, http.StatusTooManyRequests))
+	defer server.Close()
+
+	// Retry-After: 1 fica limitado a MaxBackoff, maior que o backoff normal
+	policy := RetryPolicy{MaxRetries: 1, MinBackoff: time.Millisecond, MaxBackoff: 200 * time.Millisecond}
+	start := time.Now()
+	if _, err := newTestConverter(server.URL, WithRetry(policy)).Convert(context.Background(), nil, PageRoll80mm); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < policy.MaxBackoff {
+		t.Errorf("nova tentativa após %v, esperado ao menos %v", elapsed, policy.MaxBackoff)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("esperadas 2 requisições, obtidas %d", n)
+	}
+}
+
+func TestConvertGivesUpAfterMaxRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(respondSequence(&calls, 500, 502, 503, 504, 500))
+	defer server.Close()
+
+	_, err := newTestConverter(server.URL).Convert(context.Background(), nil, PageRoll80mm)
+	var convErr *ConverterError
+	if !errors.As(err, &convErr) || convErr.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("esperado ConverterError 504, obtido %v", err)
+	}
+	if !strings.Contains(err.Error(), "falha após 4 tentativas") {
+		t.Errorf("mensagem sem o número de tentativas: %v", err)
+	}
+	if n := calls.Load(); n != int32(fastRetry.MaxRetries+1) {
+		t.Errorf("esperadas %d requisições, obtidas %d", fastRetry.MaxRetries+1, n)
+	}
+}
+
+func TestConvertDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(respondSequence(&calls, http.StatusBadRequest))
+	defer server.Close()
+
+	if _, err := newTestConverter(server.URL).Convert(context.Background(), nil, PageRoll80mm); err == nil {
+		t.Fatal("esperado erro")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("esperada 1 requisição, obtidas %d", n)
+	}
+}
+
+func TestConvertCanceledDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		http.Error(w, "indisponível", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	policy := RetryPolicy{MaxRetries: 3, MinBackoff: time.Hour, MaxBackoff: time.Hour}
+	done := make(chan error, 1)
+	go func() {
+		_, err := newTestConverter(server.URL, WithRetry(policy)).Convert(ctx, nil, PageRoll80mm)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("esperado context.Canceled, obtido %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelamento não interrompeu a espera entre tentativas")
+	}
+}
+
+func TestConvertRetriesConnectionErrors(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := "http://" + ln.Addr().String()
+	ln.Close()
+
+	_, err = newTestConverter(url).Convert(context.Background(), nil, PageRoll80mm)
+	if err == nil || !strings.Contains(err.Error(), "falha após 4 tentativas") {
+		t.Errorf("esperadas novas tentativas após conexão recusada, obtido %v", err)
+	}
+}
+
+func TestConvertDoesNotRetryInvalidURL(t *testing.T) {
+	for _, url := range []string{"http://[::1", "ftp://127.0.0.1", "127.0.0.1:3000"} {
+		// Com uma nova tentativa, a espera de uma hora estouraria o prazo
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		policy := RetryPolicy{MaxRetries: 3, MinBackoff: time.Hour, MaxBackoff: time.Hour}
+		_, err := newTestConverter(url, WithRetry(policy)).Convert(ctx, nil, PageRoll80mm)
+		cancel()
+		if err == nil || errors.Is(err, context.DeadlineExceeded) || strings.Contains(err.Error(), "tentativas") {
+			t.Errorf("%s: esperado erro sem novas tentativas, obtido %v", url, err)
+		}
+	}
+}
+
+func TestConvertSendsCredentialsAndHeaders(t *testing.T) {
+	var got *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Error(err)
+		}
+		w.Write(FakePDF)
+	}))
+	defer server.Close()
+
+	c := newTestConverter(server.URL+"/",
+		WithBasicAuth("gotenberg", "segredo"),
+		WithHeader("Gotenberg-Trace", "nfce-123"),
+		WithHeader("X-Tenant", "loja"),
+	)
+	a4 := PageSettings{WidthMM: 210, HeightMM: 297, MarginMM: 10}
+	if _, err := c.Convert(context.Background(), []byte("<html></html>"), a4); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.URL.Path != "/forms/chromium/convert/html" {
+		t.Errorf("caminho %s", got.URL.Path)
+	}
+	if user, pass, ok := got.BasicAuth(); !ok || user != "gotenberg" || pass != "segredo" {
+		t.Errorf("autenticação básica %q:%q (%v)", user, pass, ok)
+	}
+	if got.Header.Get("Gotenberg-Trace") != "nfce-123" || got.Header.Get("X-Tenant") != "loja" {
+		t.Errorf("cabeçalhos ausentes: %v", got.Header)
+	}
+	if !strings.HasPrefix(got.Header.Get("Content-Type"), "multipart/form-data") {
+		t.Errorf("Content-Type %s", got.Header.Get("Content-Type"))
+	}
+	for field, want := range map[string]string{"paperWidth": "8.27in", "paperHeight": "11.69in", "marginTop": "0.39in"} {
+		if value := got.FormValue(field); value != want {
+			t.Errorf("%s = %q, esperado %q", field, value, want)
+		}
+	}
+	if files := got.MultipartForm.File["files"]; len(files) != 1 || files[0].Filename != "index.html" {
+		t.Errorf("index.html ausente: %v", files)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" || r.Method != http.MethodGet {
+			t.Errorf("requisição inesperada: %s %s", r.Method, r.URL.Path)
+		}
+		if user, _, ok := r.BasicAuth(); !ok || user != "gotenberg" {
+			t.Error("autenticação básica ausente em /health")
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(`{"status":"down","details":{"chromium":{"status":"down"}}}`))
+	}))
+	defer server.Close()
+
+	c := newTestConverter(server.URL, WithBasicAuth("gotenberg", "segredo"))
+	if err := c.Health(context.Background()); err != nil {
+		t.Errorf("serviço disponível: %v", err)
+	}
+
+	status = http.StatusServiceUnavailable
+	err := c.Health(context.Background())
+	var convErr *ConverterError
+	if !errors.As(err, &convErr) || convErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("esperado ConverterError 503, obtido %v", err)
+	}
+	if !strings.Contains(convErr.Body, "chromium") {
+		t.Errorf("corpo da resposta ausente: %q", convErr.Body)
+	}
+}
@@ -9,7 +9,7 @@ import (
 
 // Options contém as opções para geração do DANFE
 type Options struct {
-	Format string // Formato de saída: "html" ou "pdf" (padrão: "html")
+	Format string // Formato de saída: "html", "pdf", "json", "text", "escpos", "png", "bmp", "pbm", "message" ou "message-html" (padrão: "html")
 }
 
 // GenerateDANFE gera um DANFE a partir do XML da NF-e
@@ -22,7 +22,7 @@ func GenerateDANFE(xmlContent []byte, options *Options) ([]byte, error) {
 
 	// Validar se é NFC-e
 	if !generator.IsNFCe() {
-		return nil, fmt.Errorf("apenas NFC-e (modelo 65) é suportada atualmente")
+		return nil, ErrNotNFCe
 	}
 
 	// Configurar opções padrão
@@ -42,8 +42,24 @@ func GenerateDANFE(xmlContent []byte, options *Options) ([]byte, error) {
 		format = FormatHTML
 	case "pdf":
 		format = FormatPDF
+	case "json":
+		format = FormatJSON
+	case "text":
+		format = FormatText
+	case "escpos":
+		format = FormatESCPOS
+	case "png":
+		format = FormatPNG
+	case "bmp":
+		format = FormatBMP
+	case "pbm":
+		format = FormatPBM
+	case "message":
+		format = FormatMessage
+	case "message-html":
+		format = FormatMessageHTML
 	default:
-		return nil, fmt.Errorf("formato não suportado: %s. Use 'html' ou 'pdf'", options.Format)
+		return nil, unsupportedFormat(options.Format)
 	}
 
 	// Gerar usando o novo gerador
@@ -103,7 +119,7 @@ func WriteDANFE(xmlContent []byte, writer io.Writer, options *Options) error {
 
 	// Validar se é NFC-e
 	if !generator.IsNFCe() {
-		return fmt.Errorf("apenas NFC-e (modelo 65) é suportada atualmente")
+		return ErrNotNFCe
 	}
 
 	// Configurar opções padrão
@@ -123,8 +139,24 @@ func WriteDANFE(xmlContent []byte, writer io.Writer, options *Options) error {
 		format = FormatHTML
 	case "pdf":
 		format = FormatPDF
+	case "json":
+		format = FormatJSON
+	case "text":
+		format = FormatText
+	case "escpos":
+		format = FormatESCPOS
+	case "png":
+		format = FormatPNG
+	case "bmp":
+		format = FormatBMP
+	case "pbm":
+		format = FormatPBM
+	case "message":
+		format = FormatMessage
+	case "message-html":
+		format = FormatMessageHTML
 	default:
-		return fmt.Errorf("formato não suportado: %s. Use 'html' ou 'pdf'", options.Format)
+		return unsupportedFormat(options.Format)
 	}
 
 	return generator.GenerateToWriter(writer, GenerateOptions{Format: format})
@@ -0,0 +1,68 @@
+package nfce
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/marcelo-cunha/nfce-render/converter"
+	"github.com/marcelo-cunha/nfce-render/xmlparser"
+)
+
+var (
+	// ErrNotNFCe indica que o documento não é uma NFC-e (modelo 65)
+	ErrNotNFCe = errors.New("apenas NFC-e (modelo 65) é suportada atualmente")
+
+	// ErrUnsupportedFormat indica um formato de saída desconhecido
+	ErrUnsupportedFormat = errors.New("formato não suportado")
+
+	// ErrMalformedXML indica que o XML não pôde ser interpretado.
+	// Use errors.As com *MalformedXMLError para obter linha e coluna.
+	ErrMalformedXML = xmlparser.ErrMalformedXML
+
+	// ErrNotAuthorized indica que a NF-e não foi autorizada pela SEFAZ.
+	// Use errors.As com *NotAuthorizedError para obter cStat e xMotivo.
+	ErrNotAuthorized = errors.New("NF-e não autorizada")
+
+	// ErrConversion indica que a conversão do DANFE em HTML para PDF falhou.
+	// Envolve o erro do conversor, que continua acessível com errors.Is e
+	// errors.As, e ErrNoConverter.
+	ErrConversion = errors.New("erro ao converter para PDF")
+
+	// ErrNoConverter indica que o backend PDFHTML foi escolhido sem um
+	// conversor configurado com WithConverter
+	ErrNoConverter = errors.New("nenhum conversor de HTML para PDF configurado")
+
+	// ErrTemplateNativePDF indica que o backend PDFNative foi escolhido junto
+	// com um template personalizado, que ele não usa
+	ErrTemplateNativePDF = errors.New("o backend PDFNative não usa templates HTML")
+)
+
+// MalformedXMLError descreve onde o parse do XML falhou
+type MalformedXMLError = xmlparser.MalformedXMLError
+
+// ConverterError indica que o serviço de conversão para PDF respondeu com erro
+type ConverterError = converter.ConverterError
+
+// NotAuthorizedError informa a situação do protocolo de uma NF-e não autorizada
+type NotAuthorizedError struct {
+	CStat   string
+	XMotivo string
+}
+
+// Error implementa a interface error
+func (e *NotAuthorizedError) Error() string {
+	if e.CStat == "" {
+		return "NF-e não autorizada: protocolo de autorização ausente"
+	}
+	return fmt.Sprintf("NF-e não autorizada (cStat %s): %s", e.CStat, e.XMotivo)
+}
+
+// Is permite comparar o erro com ErrNotAuthorized usando errors.Is
+func (e *NotAuthorizedError) Is(target error) bool {
+	return target == ErrNotAuthorized
+}
+
+// unsupportedFormat retorna ErrUnsupportedFormat com o formato informado
+func unsupportedFormat(format string) error {
+	return fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+}
@@ -0,0 +1,53 @@
+package nfce
+
+import (
+	"fmt"
+
+	"github.com/marcelo-cunha/nfce-render/xmlparser"
+)
+
+// AddEvent anexa um evento da NF-e (procEventoNFe) ao gerador.
+// O evento precisa se referir à mesma chave de acesso da NF-e.
+func (g *Generator) AddEvent(ev *xmlparser.ProcEventoNFe) error {
+	if ev == nil {
+		return fmt.Errorf("evento não informado")
+	}
+	if ev.GetChaveAcesso() != g.nfe.GetChaveAcesso() {
+		return fmt.Errorf("evento da chave %s não pertence à NF-e %s", ev.GetChaveAcesso(), g.nfe.GetChaveAcesso())
+	}
+
+	g.events = append(g.events, ev)
+	return nil
+}
+
+// AddEventXML faz o parse de um XML de evento e o anexa ao gerador. O
+// conteúdo não modelado, como a assinatura, é preservado para que o evento
+// possa ser reescrito por ProcEventoNFe.Marshal, como faz o pacote mailer.
+func (g *Generator) AddEventXML(xmlContent []byte) error {
+	ev, err := xmlparser.ParseEventoXMLWithOptions(xmlContent, xmlparser.ParseOptions{Preserve: true})
+	if err != nil {
+		return err
+	}
+	return g.AddEvent(ev)
+}
+
+// GetEvents retorna os eventos anexados ao gerador
+func (g *Generator) GetEvents() []*xmlparser.ProcEventoNFe {
+	return g.events
+}
+
+// GetCancelamento retorna o evento de cancelamento registrado na SEFAZ,
+// ou nil se a NF-e não foi cancelada
+func (g *Generator) GetCancelamento() *xmlparser.ProcEventoNFe {
+	for _, ev := range g.events {
+		if ev.IsCancelamento() && ev.IsRegistrado() {
+			return ev
+		}
+	}
+	return nil
+}
+
+// IsCancelada verifica se há um cancelamento válido anexado ao gerador
+func (g *Generator) IsCancelada() bool {
+	return g.GetCancelamento() != nil
+}
@@ -4,4 +4,7 @@ go 1.21
 
 require github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 
-require github.com/joho/godotenv v1.5.1
+require (
+	golang.org/x/image v0.18.0
+	golang.org/x/text v0.16.0 // indirect
+)
@@ -0,0 +1,143 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	nfce "github.com/marcelo-cunha/nfce-render"
+	"github.com/marcelo-cunha/nfce-render/renderer"
+	"github.com/marcelo-cunha/nfce-render/xmlparser"
+)
+
+// DefaultSubject é o template padrão do assunto. Os campos disponíveis são
+// os de nfce.Summary.
+const DefaultSubject = "NFC-e nº {{.Numero}} - {{.NomeEmitente}}"
+
+// Options define o conteúdo da mensagem montada por Compose
+type Options struct {
+	From    string
+	To      []string
+	Cc      []string
+	Bcc     []string
+	ReplyTo string
+
+	// Subject é um template text/template sobre nfce.Summary
+	// (padrão: DefaultSubject)
+	Subject string
+
+	// XML é o XML original da NF-e. Quando vazio, é usado o XML do Generator.
+	XML []byte
+
+	// AttachPDF anexa o DANFE em PDF. Quando PDF é vazio o arquivo é gerado
+	// pelo Generator com PDFOptions (o formato é sempre PDF).
+	AttachPDF  bool
+	PDF        []byte
+	PDFOptions nfce.GenerateOptions
+	// SkipEvents deixa de anexar os XML dos eventos (como o cancelamento)
+	// adicionados ao Generator
+	SkipEvents bool
+}
+
+// Compose monta a mensagem de uma NFC-e: corpo HTML com o DANFE (QR Code e
+// logotipo como imagens inline), corpo alternativo em texto com o DANFE em
+// mensagem eletrônica, o XML da NF-e e dos eventos e, opcionalmente, o PDF
+func Compose(g *nfce.Generator, opts Options) (*Message, error) {
+	subject, err := subjectFor(g, opts.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := g.EmailRenderer(nfce.GenerateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var html bytes.Buffer
+	if err := r.RenderToWriter(&html); err != nil {
+		return nil, err
+	}
+
+	var text bytes.Buffer
+	if err := g.GenerateToWriter(&text, nfce.GenerateOptions{Format: nfce.FormatMessage}); err != nil {
+		return nil, err
+	}
+
+	m := &Message{
+		From:    opts.From,
+		To:      opts.To,
+		Cc:      opts.Cc,
+		Bcc:     opts.Bcc,
+		ReplyTo: opts.ReplyTo,
+		Subject: subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+	}
+
+	qr, err := r.QRCodePNG()
+	if err != nil {
+		return nil, err
+	}
+	if qr != nil {
+		m.Inline = append(m.Inline, Attachment{Filename: "qrcode.png", ContentType: "image/png", ContentID: renderer.EmailQRCodeCID, Data: qr})
+	}
+	if logo := r.EmailLogo(); logo != nil {
+		ext := ".png"
+		if logo.MimeType() == renderer.LogoJPEG {
+			ext = ".jpg"
+		}
+		m.Inline = append(m.Inline, Attachment{Filename: "logo" + ext, ContentType: logo.MimeType(), ContentID: renderer.EmailLogoCID, Data: logo.Bytes()})
+	}
+
+	chave := g.GetNFe().GetChaveAcesso()
+	xml := opts.XML
+	if len(xml) == 0 {
+		xml = g.GetXML()
+	}
+	m.Attachments = append(m.Attachments, Attachment{Filename: chave + "-procNFe.xml", ContentType: "application/xml", Data: xml})
+
+	if !opts.SkipEvents {
+		for _, ev := range g.GetEvents() {
+			data, err := ev.Marshal(xmlparser.MarshalOptions{Header: true})
+			if err != nil {
+				return nil, fmt.Errorf("erro ao gerar XML do evento: %w", err)
+			}
+			inf := ev.Evento.InfEvento
+			name := fmt.Sprintf("%s-%s-%s-procEventoNFe.xml", chave, inf.TpEvento, inf.NSeqEvento)
+			m.Attachments = append(m.Attachments, Attachment{Filename: name, ContentType: "application/xml", Data: data})
+		}
+	}
+
+	if opts.AttachPDF {
+		pdf := opts.PDF
+		if len(pdf) == 0 {
+			var buf bytes.Buffer
+			pdfOptions := opts.PDFOptions
+			pdfOptions.Format = nfce.FormatPDF
+			if err := g.GenerateToWriter(&buf, pdfOptions); err != nil {
+				return nil, err
+			}
+			pdf = buf.Bytes()
+		}
+		m.Attachments = append(m.Attachments, Attachment{Filename: chave + ".pdf", ContentType: "application/pdf", Data: pdf})
+	}
+
+	return m, nil
+}
+
+// subjectFor executa o template do assunto sobre o resumo da NFC-e
+func subjectFor(g *nfce.Generator, text string) (string, error) {
+	if text == "" {
+		text = DefaultSubject
+	}
+	tmpl, err := template.New("subject").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("erro ao fazer parse do template do assunto: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, g.Summary()); err != nil {
+		return "", fmt.Errorf("erro ao executar template do assunto: %w", err)
+	}
+	// Quebras de linha não são permitidas no cabeçalho
+	return strings.Join(strings.Fields(buf.String()), " "), nil
+}
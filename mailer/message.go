@@ -0,0 +1,291 @@
+// Package mailer monta e envia por e-mail o DANFE NFC-e, com o XML da NF-e e,
+// opcionalmente, o PDF anexados
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Attachment é um arquivo anexado à mensagem. Com ContentID o arquivo é
+// anexado inline e pode ser referenciado no HTML por "cid:<ContentID>".
+type Attachment struct {
+	Filename    string
+	ContentType string
+	ContentID   string
+	Data        []byte
+}
+
+// Message é uma mensagem de e-mail no formato RFC 5322
+type Message struct {
+	From    string // endereço do remetente, com ou sem nome
+	To      []string
+	Cc      []string
+	Bcc     []string // apenas destinatários do envelope, não aparecem no cabeçalho
+	ReplyTo string
+	Subject string
+
+	Text string // corpo alternativo em texto simples
+	HTML string
+
+	Inline      []Attachment // imagens referenciadas pelo HTML
+	Attachments []Attachment
+
+	Date      time.Time // padrão: hora do envio
+	MessageID string    // padrão: gerado a partir do domínio do remetente
+}
+
+// Sender retorna o endereço do remetente usado no envelope SMTP
+func (m *Message) Sender() (string, error) {
+	addr, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return "", fmt.Errorf("remetente inválido %q: %w", m.From, err)
+	}
+	return addr.Address, nil
+}
+
+// Recipients retorna os endereços de todos os destinatários (To, Cc e Bcc)
+// usados no envelope SMTP
+func (m *Message) Recipients() ([]string, error) {
+	var recipients []string
+	for _, list := range [][]string{m.To, m.Cc, m.Bcc} {
+		for _, raw := range list {
+			addr, err := mail.ParseAddress(raw)
+			if err != nil {
+				return nil, fmt.Errorf("destinatário inválido %q: %w", raw, err)
+			}
+			recipients = append(recipients, addr.Address)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("mensagem sem destinatários")
+	}
+	return recipients, nil
+}
+
+// Bytes retorna a mensagem codificada, pronta para o comando DATA do SMTP
+func (m *Message) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteTo escreve a mensagem codificada no io.Writer. A estrutura é
+// multipart/mixed com o corpo em multipart/alternative (texto e HTML com as
+// imagens inline em multipart/related) seguido dos anexos.
+func (m *Message) WriteTo(w io.Writer) (int64, error) {
+	header, err := m.header()
+	if err != nil {
+		return 0, err
+	}
+
+	var buf bytes.Buffer
+	mixed := multipart.NewWriter(&buf)
+	header.Set("Content-Type", "multipart/mixed; boundary="+mixed.Boundary())
+	if err := m.writeBody(mixed); err != nil {
+		return 0, err
+	}
+	for _, a := range m.Attachments {
+		if err := writeAttachment(mixed, a, "attachment"); err != nil {
+			return 0, err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return 0, err
+	}
+
+	var out bytes.Buffer
+	for _, key := range headerOrder {
+		for _, value := range header[key] {
+			out.WriteString(key + ": " + value + "\r\n")
+		}
+	}
+	out.WriteString("\r\n")
+	out.Write(buf.Bytes())
+	return out.WriteTo(w)
+}
+
+// headerOrder é a ordem em que os cabeçalhos são escritos
+var headerOrder = []string{"From", "To", "Cc", "Reply-To", "Subject", "Date", "Message-Id", "Mime-Version", "Content-Type"}
+
+// header monta os cabeçalhos da mensagem, validando os endereços
+func (m *Message) header() (textproto.MIMEHeader, error) {
+	h := textproto.MIMEHeader{}
+
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return nil, fmt.Errorf("remetente inválido %q: %w", m.From, err)
+	}
+	h.Set("From", from.String())
+
+	for key, list := range map[string][]string{"To": m.To, "Cc": m.Cc} {
+		if len(list) == 0 {
+			continue
+		}
+		formatted := make([]string, 0, len(list))
+		for _, raw := range list {
+			addr, err := mail.ParseAddress(raw)
+			if err != nil {
+				return nil, fmt.Errorf("destinatário inválido %q: %w", raw, err)
+			}
+			formatted = append(formatted, addr.String())
+		}
+		h.Set(key, strings.Join(formatted, ", "))
+	}
+	if m.ReplyTo != "" {
+		addr, err := mail.ParseAddress(m.ReplyTo)
+		if err != nil {
+			return nil, fmt.Errorf("endereço de resposta inválido %q: %w", m.ReplyTo, err)
+		}
+		h.Set("Reply-To", addr.String())
+	}
+
+	h.Set("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+
+	date := m.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	h.Set("Date", date.Format(time.RFC1123Z))
+
+	id := m.MessageID
+	if strings.ContainsAny(id, "\r\n") {
+		return nil, fmt.Errorf("Message-ID inválido %q: contém quebra de linha", id)
+	}
+	if id == "" {
+		id = newMessageID(from.Address)
+	}
+	h.Set("Message-Id", "<"+strings.Trim(id, "<>")+">")
+	h.Set("Mime-Version", "1.0")
+	return h, nil
+}
+
+// writeBody escreve o corpo da mensagem como multipart/alternative
+func (m *Message) writeBody(mixed *multipart.Writer) error {
+	var body bytes.Buffer
+	alternative := multipart.NewWriter(&body)
+
+	if m.Text != "" {
+		if err := writeText(alternative, "text/plain; charset=utf-8", m.Text); err != nil {
+			return err
+		}
+	}
+
+	if m.HTML != "" {
+		if len(m.Inline) == 0 {
+			if err := writeText(alternative, "text/html; charset=utf-8", m.HTML); err != nil {
+				return err
+			}
+		} else {
+			var rel bytes.Buffer
+			related := multipart.NewWriter(&rel)
+			if err := writeText(related, "text/html; charset=utf-8", m.HTML); err != nil {
+				return err
+			}
+			for _, a := range m.Inline {
+				if err := writeAttachment(related, a, "inline"); err != nil {
+					return err
+				}
+			}
+			if err := related.Close(); err != nil {
+				return err
+			}
+			part, err := alternative.CreatePart(textproto.MIMEHeader{
+				"Content-Type": {`multipart/related; type="text/html"; boundary=` + related.Boundary()},
+			})
+			if err != nil {
+				return err
+			}
+			if _, err := part.Write(rel.Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := alternative.Close(); err != nil {
+		return err
+	}
+	part, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + alternative.Boundary()},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(body.Bytes())
+	return err
+}
+
+// writeText escreve uma parte de texto em quoted-printable
+func writeText(w *multipart.Writer, contentType, text string) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	// Quebras CRLF viram LF antes da conversão, para não gerar CR CR LF
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	qp := quotedprintable.NewWriter(part)
+	if _, err := io.WriteString(qp, strings.ReplaceAll(text, "\n", "\r\n")); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// writeAttachment escreve um arquivo em base64, com linhas de 76 caracteres
+func writeAttachment(w *multipart.Writer, a Attachment, disposition string) error {
+	contentType := a.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h := textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType(contentType, map[string]string{"name": a.Filename})},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename})},
+	}
+	if strings.ContainsAny(a.ContentID, "\r\n") {
+		return fmt.Errorf("Content-ID inválido %q: contém quebra de linha", a.ContentID)
+	}
+	if a.ContentID != "" {
+		h.Set("Content-ID", "<"+a.ContentID+">")
+	}
+	part, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(a.Data)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(part, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err = io.WriteString(part, encoded+"\r\n")
+	return err
+}
+
+// newMessageID gera um Message-ID aleatório no domínio do remetente
+func newMessageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndexByte(from, '@'); at >= 0 {
+		domain = from[at+1:]
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b) + "@" + domain
+}
@@ -0,0 +1,155 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+)
+
+// Transport entrega mensagens já codificadas. Implementações alternativas
+// permitem enviar por APIs de provedores ou capturar mensagens em testes.
+type Transport interface {
+	Send(ctx context.Context, from string, to []string, msg []byte) error
+}
+
+// Security define a criptografia da conexão SMTP
+type Security int
+
+const (
+	// SecurityStartTLS exige STARTTLS antes da autenticação (padrão, porta 587)
+	SecurityStartTLS Security = iota
+	// SecurityTLS usa TLS desde a conexão (porta 465)
+	SecurityTLS
+	// SecurityNone não criptografa a conexão. Use apenas com servidores
+	// locais, como os de teste.
+	SecurityNone
+)
+
+// SMTPTransport envia mensagens por um servidor SMTP
+type SMTPTransport struct {
+	Host string
+	Port int // padrão: 587, ou 465 com SecurityTLS
+
+	// Username e Password habilitam a autenticação PLAIN
+	Username string
+	Password string
+
+	Security  Security
+	TLSConfig *tls.Config // padrão: verifica o certificado de Host
+	LocalName string      // nome usado no EHLO (padrão: localhost)
+}
+
+// Send envia a mensagem pelo Transport
+func Send(ctx context.Context, t Transport, m *Message) error {
+	from, err := m.Sender()
+	if err != nil {
+		return err
+	}
+	to, err := m.Recipients()
+	if err != nil {
+		return err
+	}
+	data, err := m.Bytes()
+	if err != nil {
+		return fmt.Errorf("erro ao codificar mensagem: %w", err)
+	}
+	return t.Send(ctx, from, to, data)
+}
+
+// Send entrega a mensagem ao servidor SMTP. O cancelamento de ctx interrompe
+// a conexão em andamento.
+func (t *SMTPTransport) Send(ctx context.Context, from string, to []string, msg []byte) error {
+	err := t.send(ctx, from, to, msg)
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func (t *SMTPTransport) send(ctx context.Context, from string, to []string, msg []byte) error {
+	port := t.Port
+	if port == 0 {
+		port = 587
+		if t.Security == SecurityTLS {
+			port = 465
+		}
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(t.Host, strconv.Itoa(port)))
+	if err != nil {
+		return fmt.Errorf("erro ao conectar ao servidor SMTP: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if t.Security == SecurityTLS {
+		conn = tls.Client(conn, t.tlsConfig())
+	}
+
+	client, err := smtp.NewClient(conn, t.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("erro ao iniciar sessão SMTP: %w", err)
+	}
+	defer client.Close()
+
+	if t.LocalName != "" {
+		if err := client.Hello(t.LocalName); err != nil {
+			return fmt.Errorf("erro no EHLO: %w", err)
+		}
+	}
+
+	if t.Security == SecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("servidor SMTP %s não oferece STARTTLS", t.Host)
+		}
+		if err := client.StartTLS(t.tlsConfig()); err != nil {
+			return fmt.Errorf("erro no STARTTLS: %w", err)
+		}
+	}
+
+	if t.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("servidor SMTP %s não oferece autenticação", t.Host)
+		}
+		if err := client.Auth(smtp.PlainAuth("", t.Username, t.Password, t.Host)); err != nil {
+			return fmt.Errorf("erro na autenticação SMTP: %w", err)
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("remetente recusado: %w", err)
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("destinatário %s recusado: %w", rcpt, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("erro ao enviar mensagem: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("erro ao enviar mensagem: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("mensagem recusada: %w", err)
+	}
+	return client.Quit()
+}
+
+// tlsConfig retorna a configuração TLS, verificando o certificado de Host
+func (t *SMTPTransport) tlsConfig() *tls.Config {
+	if t.TLSConfig != nil {
+		return t.TLSConfig
+	}
+	return &tls.Config{ServerName: t.Host}
+}
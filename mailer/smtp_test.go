@@ -0,0 +1,404 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	nfce "github.com/marcelo-cunha/nfce-render"
+	"github.com/marcelo-cunha/nfce-render/renderer"
+)
+
+// smtpSession registra o que o servidor de teste recebeu
+type smtpSession struct {
+	commands []string
+	tlsAuth  bool // AUTH recebido depois do STARTTLS
+	auth     string
+	from     string
+	rcpt     []string
+	data     []byte
+}
+
+// smtpServer é um servidor SMTP mínimo para testes, sobre um net.Listener
+type smtpServer struct {
+	ln       net.Listener
+	tls      *tls.Config
+	startTLS bool // anuncia STARTTLS
+
+	mu      sync.Mutex
+	session smtpSession
+	done    chan struct{}
+}
+
+// newSMTPServer inicia o servidor e retorna a configuração TLS do cliente
+func newSMTPServer(t *testing.T, startTLS bool) (*smtpServer, *tls.Config) {
+	t.Helper()
+	cert, pool := testCertificate(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpServer{
+		ln:       ln,
+		tls:      &tls.Config{Certificates: []tls.Certificate{cert}},
+		startTLS: startTLS,
+		done:     make(chan struct{}),
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		defer close(s.done)
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s.serve(conn)
+	}()
+	return s, &tls.Config{RootCAs: pool, ServerName: "127.0.0.1"}
+}
+
+// transport retorna um SMTPTransport apontado para o servidor
+func (s *smtpServer) transport(clientTLS *tls.Config) *SMTPTransport {
+	addr := s.ln.Addr().(*net.TCPAddr)
+	return &SMTPTransport{
+		Host:      "127.0.0.1",
+		Port:      addr.Port,
+		Username:  "loja",
+		Password:  "segredo",
+		TLSConfig: clientTLS,
+	}
+}
+
+// result espera o fim da conexão e retorna a sessão registrada
+func (s *smtpServer) result(t *testing.T) smtpSession {
+	t.Helper()
+	select {
+	case <-s.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("servidor SMTP não terminou a sessão")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.session
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	tp := textproto.NewConn(conn)
+	secure := false
+	tp.PrintfLine("220 127.0.0.1 ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		verb = strings.ToUpper(verb)
+
+		s.mu.Lock()
+		s.session.commands = append(s.session.commands, verb)
+		s.mu.Unlock()
+
+		switch verb {
+		case "EHLO", "HELO":
+			lines := []string{"127.0.0.1"}
+			if s.startTLS && !secure {
+				lines = append(lines, "STARTTLS")
+			}
+			lines = append(lines, "AUTH PLAIN")
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				tp.PrintfLine("250%s%s", sep, l)
+			}
+		case "STARTTLS":
+			tp.PrintfLine("220 pronto")
+			tlsConn := tls.Server(conn, s.tls)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, tp, secure = tlsConn, textproto.NewConn(tlsConn), true
+		case "AUTH":
+			mechanism, initial, _ := strings.Cut(arg, " ")
+			decoded, err := base64.StdEncoding.DecodeString(initial)
+			if mechanism != "PLAIN" || err != nil {
+				tp.PrintfLine("504 mecanismo não suportado")
+				continue
+			}
+			s.mu.Lock()
+			s.session.auth, s.session.tlsAuth = string(decoded), secure
+			s.mu.Unlock()
+			tp.PrintfLine("235 autenticado")
+		case "MAIL":
+			s.mu.Lock()
+			s.session.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			s.mu.Unlock()
+			tp.PrintfLine("250 ok")
+		case "RCPT":
+			s.mu.Lock()
+			s.session.rcpt = append(s.session.rcpt, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			s.mu.Unlock()
+			tp.PrintfLine("250 ok")
+		case "DATA":
+			tp.PrintfLine("354 envie a mensagem")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.session.data = data
+			s.mu.Unlock()
+			tp.PrintfLine("250 aceita")
+		case "QUIT":
+			tp.PrintfLine("221 até logo")
+			return
+		default:
+			tp.PrintfLine("502 comando não implementado")
+		}
+	}
+}
+
+// testCertificate gera um certificado autoassinado para 127.0.0.1
+func testCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+// mimePart é uma parte folha da mensagem, já decodificada
+type mimePart struct {
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// readParts percorre a mensagem multipart e retorna as partes folha
+func readParts(t *testing.T, contentType string, body io.Reader) []mimePart {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return []mimePart{{body: data}}
+	}
+
+	var parts []mimePart
+	mr := multipart.NewReader(body, params["boundary"])
+	for {
+		p, err := mr.NextRawPart()
+		if err == io.EOF {
+			return parts
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(p.Header.Get("Content-Type"), "multipart/") {
+			parts = append(parts, readParts(t, p.Header.Get("Content-Type"), p)...)
+			continue
+		}
+		data, err := io.ReadAll(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Header.Get("Content-Transfer-Encoding") == "base64" {
+			if data, err = base64.StdEncoding.DecodeString(strings.NewReplacer("\r", "", "\n", "").Replace(string(data))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		parts = append(parts, mimePart{header: p.Header, body: data})
+	}
+}
+
+// composeTestMessage monta a mensagem da NFC-e de testdata
+func composeTestMessage(t *testing.T) (*Message, []byte) {
+	t.Helper()
+	xmlContent, err := os.ReadFile("testdata/nfce.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := nfce.NewGenerator(xmlContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Compose(g, Options{
+		From: "Loja Exemplo <nfce@loja.com.br>",
+		To:   []string{"cliente@example.com"},
+		Bcc:  []string{"arquivo@loja.com.br"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, xmlContent
+}
+
+func TestSMTPTransportStartTLS(t *testing.T) {
+	server, clientTLS := newSMTPServer(t, true)
+	m, xmlContent := composeTestMessage(t)
+
+	if err := Send(context.Background(), server.transport(clientTLS), m); err != nil {
+		t.Fatal(err)
+	}
+	session := server.result(t)
+
+	if want := "\x00loja\x00segredo"; session.auth != want || !session.tlsAuth {
+		t.Errorf("AUTH PLAIN %q (depois do STARTTLS: %v), esperado %q com TLS", session.auth, session.tlsAuth, want)
+	}
+	if session.from != "nfce@loja.com.br" {
+		t.Errorf("MAIL FROM %q", session.from)
+	}
+	if strings.Join(session.rcpt, ",") != "cliente@example.com,arquivo@loja.com.br" {
+		t.Errorf("RCPT TO %v", session.rcpt)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(session.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bcc := msg.Header.Get("Bcc"); bcc != "" {
+		t.Errorf("cabeçalho Bcc enviado: %s", bcc)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "NFC-e nº 123 - LOJA EXEMPLO LTDA" {
+		t.Errorf("assunto %q (%v)", subject, err)
+	}
+
+	var html, qrcode, attachment *mimePart
+	for _, p := range readParts(t, msg.Header.Get("Content-Type"), msg.Body) {
+		p := p
+		switch {
+		case strings.HasPrefix(p.header.Get("Content-Type"), "text/html"):
+			html = &p
+		case p.header.Get("Content-Id") == "<"+renderer.EmailQRCodeCID+">":
+			qrcode = &p
+		case strings.HasPrefix(p.header.Get("Content-Disposition"), "attachment"):
+			attachment = &p
+		}
+	}
+
+	if html == nil || !strings.Contains(string(html.body), "cid:"+renderer.EmailQRCodeCID) {
+		t.Error("corpo HTML sem referência ao QR Code")
+	}
+	if qrcode == nil || !bytes.HasPrefix(qrcode.body, []byte("\x89PNG")) {
+		t.Error("QR Code inline ausente ou não é PNG")
+	} else if !strings.HasPrefix(qrcode.header.Get("Content-Disposition"), "inline") {
+		t.Errorf("QR Code com disposição %q", qrcode.header.Get("Content-Disposition"))
+	}
+	if attachment == nil {
+		t.Fatal("XML da NF-e não anexado")
+	}
+	_, params, _ := mime.ParseMediaType(attachment.header.Get("Content-Disposition"))
+	if params["filename"] != "13240112345678000195650010000001231000001236-procNFe.xml" {
+		t.Errorf("nome do anexo %q", params["filename"])
+	}
+	if !bytes.Equal(attachment.body, xmlContent) {
+		t.Error("XML anexado difere do original")
+	}
+}
+
+func TestSMTPTransportStartTLSRefused(t *testing.T) {
+	server, clientTLS := newSMTPServer(t, false)
+	m, _ := composeTestMessage(t)
+
+	err := Send(context.Background(), server.transport(clientTLS), m)
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Fatalf("esperado erro de STARTTLS, obtido %v", err)
+	}
+	session := server.result(t)
+	for _, verb := range session.commands {
+		if verb == "AUTH" || verb == "MAIL" || verb == "DATA" {
+			t.Errorf("%s enviado sem STARTTLS", verb)
+		}
+	}
+}
+
+func TestMessageRejectsHeaderInjection(t *testing.T) {
+	m := &Message{From: "nfce@loja.com.br", To: []string{"cliente@example.com"}, Subject: "NFC-e"}
+
+	m.MessageID = "abc@loja.com.br\r\nBcc: outro@example.com"
+	if _, err := m.Bytes(); err == nil {
+		t.Error("Message-ID com CRLF aceito")
+	}
+	m.MessageID = "abc@loja.com.br\nX-Injetado: 1"
+	if _, err := m.Bytes(); err == nil {
+		t.Error("Message-ID com LF aceito")
+	}
+
+	m.MessageID = ""
+	m.HTML = "<p>oi</p>"
+	m.Inline = []Attachment{{Filename: "a.png", ContentID: "a\r\nX-Injetado: 1", Data: []byte{1}}}
+	if _, err := m.Bytes(); err == nil {
+		t.Error("Content-ID com CRLF aceito")
+	}
+}
+
+func TestWriteTextNormalizesLineEndings(t *testing.T) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if err := writeText(w, "text/plain; charset=utf-8", "Olá\r\nNFC-e nº 123\nTotal: R$ 10,00\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("=0D")) {
+		t.Errorf("CR codificado no corpo: %q", buf.String())
+	}
+
+	part, err := multipart.NewReader(&buf, w.Boundary()).NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := io.ReadAll(part)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Olá\r\nNFC-e nº 123\r\nTotal: R$ 10,00\r\n"; string(text) != want {
+		t.Errorf("texto %q, esperado %q", text, want)
+	}
+}
@@ -2,6 +2,7 @@ package nfce
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -15,51 +16,180 @@ import (
 type Format string
 
 const (
-	FormatHTML Format = "html"
-	FormatPDF  Format = "pdf"
+	FormatHTML   Format = "html"
+	FormatPDF    Format = "pdf"
+	FormatJSON   Format = "json"
+	FormatText   Format = "text"   // texto monoespaçado para impressoras térmicas
+	FormatESCPOS Format = "escpos" // comandos ESC/POS para impressoras térmicas
+	FormatPNG    Format = "png"    // imagem em tons de cinza
+	FormatBMP    Format = "bmp"    // imagem de 1 bit
+	FormatPBM    Format = "pbm"    // imagem de 1 bit (netpbm)
+
+	// DANFE NFC-e em mensagem eletrônica, sem a lista de itens
+	FormatMessage     Format = "message"      // texto simples, para SMS
+	FormatMessageHTML Format = "message-html" // trecho HTML, para e-mail e aplicativos
+)
+
+// Paper representa os perfis de papel suportados
+type Paper string
+
+const (
+	Paper80mm         Paper = "80mm" // bobina térmica de 80mm (padrão)
+	Paper58mm         Paper = "58mm" // bobina térmica de 58mm
+	PaperA4           Paper = "a4"   // A4 retrato com o DANFE centralizado
+	PaperA4TwoColumns Paper = "a4-2col"
+)
+
+// profile retorna o perfil do renderizador correspondente ao papel
+func (p Paper) profile() (renderer.PaperProfile, error) {
+	switch p {
+	case "", Paper80mm:
+		return renderer.Paper80mm, nil
+	case Paper58mm:
+		return renderer.Paper58mm, nil
+	case PaperA4:
+		return renderer.PaperA4, nil
+	case PaperA4TwoColumns:
+		return renderer.PaperA4TwoColumns, nil
+	default:
+		return renderer.PaperProfile{}, fmt.Errorf("papel não suportado: %s", p)
+	}
+}
+
+// Printer representa os perfis de impressora da saída ESC/POS
+type Printer string
+
+const (
+	PrinterEpson    Printer = "epson" // Epson e compatíveis (padrão)
+	PrinterBematech Printer = "bematech"
+	PrinterElgin    Printer = "elgin"
+	PrinterDaruma   Printer = "daruma"
+)
+
+// profile retorna o perfil de comandos da impressora
+func (p Printer) profile() (renderer.PrinterProfile, error) {
+	switch p {
+	case "", PrinterEpson:
+		return renderer.PrinterEpson, nil
+	case PrinterBematech:
+		return renderer.PrinterBematech, nil
+	case PrinterElgin:
+		return renderer.PrinterElgin, nil
+	case PrinterDaruma:
+		return renderer.PrinterDaruma, nil
+	default:
+		return renderer.PrinterProfile{}, fmt.Errorf("impressora não suportada: %s", p)
+	}
+}
+
+// CopiesMode define quantas vias do DANFE são geradas
+type CopiesMode int
+
+const (
+	// CopiesAuto gera duas vias em contingência offline e uma via nos demais casos
+	CopiesAuto CopiesMode = iota
+	// CopiesSingle gera sempre uma única via
+	CopiesSingle
+	// CopiesBoth gera sempre a via do consumidor e a via do estabelecimento
+	CopiesBoth
 )
 
 // GenerateOptions contém as opções para geração do DANFE
 type GenerateOptions struct {
 	Format Format
+	Copies CopiesMode
+	Paper  Paper
+	// Columns é o número de colunas do formato texto: 32, 42 ou 48
+	// (padrão: 32 no papel de 58mm e 48 nos demais)
+	Columns int
+	// Printer é o perfil de impressora do formato ESC/POS (padrão: epson)
+	Printer Printer
+	// DPI é a resolução dos formatos de imagem: 203 ou 300 (padrão: 203)
+	DPI int
+	// Dots é a largura dos formatos de imagem em pontos: 384, 576 ou 640
+	// (padrão: 384 no papel de 58mm e 576 nos demais)
+	Dots int
+	// PDFA é a conformidade PDF/A do formato PDF: PDFA2B ou PDFA3B, que
+	// embute o XML original (padrão: PDF comum)
+	PDFA PDFA
 }
 
 // Generator é responsável pela geração de DANFEs
 type Generator struct {
-	nfe *xmlparser.NFeProc
+	nfe              *xmlparser.NFeProc
+	xml              []byte
+	timezone         TimezonePolicy
+	statusPolicy     StatusPolicy
+	forceHomologacao bool
+	events           []*xmlparser.ProcEventoNFe
+	template         *renderer.Template
+	logo             *renderer.Logo
+	qrcode           renderer.QRCodeOptions
+	pdfBackend       PDFBackend
+	converter        converter.Converter
 }
 
 // NewGenerator cria uma nova instância do gerador
-func NewGenerator(xmlContent []byte) (*Generator, error) {
+func NewGenerator(xmlContent []byte, opts ...Option) (*Generator, error) {
 	nfe, err := xmlparser.ParseXML(xmlContent)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao fazer parse do XML: %w", err)
 	}
 
-	return &Generator{
+	g := &Generator{
 		nfe: nfe,
-	}, nil
+		xml: xmlContent,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g, nil
 }
 
 // NewGeneratorFromFile cria uma nova instância do gerador a partir de um arquivo
-func NewGeneratorFromFile(xmlPath string) (*Generator, error) {
+func NewGeneratorFromFile(xmlPath string, opts ...Option) (*Generator, error) {
 	xmlContent, err := os.ReadFile(xmlPath)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao ler arquivo XML: %w", err)
 	}
 
-	return NewGenerator(xmlContent)
+	return NewGenerator(xmlContent, opts...)
 }
 
 // GenerateToWriter gera o DANFE e escreve no writer fornecido
 func (g *Generator) GenerateToWriter(writer io.Writer, options GenerateOptions) error {
+	return g.GenerateToWriterContext(context.Background(), writer, options)
+}
+
+// GenerateToWriterContext gera o DANFE e escreve no writer fornecido. ctx é
+// repassado ao conversor de PDF; os demais formatos não o consultam.
+func (g *Generator) GenerateToWriterContext(ctx context.Context, writer io.Writer, options GenerateOptions) error {
+	if err := g.checkStatus(); err != nil {
+		return err
+	}
+	if _, err := options.Paper.profile(); err != nil {
+		return err
+	}
+
 	switch options.Format {
 	case FormatHTML:
-		return g.generateHTML(writer)
+		return g.generateHTML(writer, options)
 	case FormatPDF:
-		return g.generatePDF(writer)
+		return g.generatePDF(ctx, writer, options)
+	case FormatJSON:
+		return g.generateJSON(writer, options)
+	case FormatText:
+		return g.generateText(writer, options)
+	case FormatESCPOS:
+		return g.generateESCPOS(writer, options)
+	case FormatPNG, FormatBMP, FormatPBM:
+		return g.generateImage(writer, options)
+	case FormatMessage:
+		return renderer.NewMessageRenderer(g.nfe, g.rendererOptions(options)...).RenderText(writer)
+	case FormatMessageHTML:
+		return renderer.NewMessageRenderer(g.nfe, g.rendererOptions(options)...).RenderHTML(writer)
 	default:
-		return fmt.Errorf("formato não suportado: %s", options.Format)
+		return unsupportedFormat(string(options.Format))
 	}
 }
 
@@ -75,15 +205,30 @@ func (g *Generator) GenerateToFile(outputPath string, options GenerateOptions) e
 }
 
 // generateHTML gera o DANFE em formato HTML
-func (g *Generator) generateHTML(writer io.Writer) error {
-	htmlRenderer := renderer.NewHTMLRenderer(g.nfe)
+func (g *Generator) generateHTML(writer io.Writer, options GenerateOptions) error {
+	htmlRenderer := renderer.NewHTMLRenderer(g.nfe, g.rendererOptions(options)...)
 	return htmlRenderer.RenderToWriter(writer)
 }
 
 // generatePDF gera o DANFE em formato PDF
-func (g *Generator) generatePDF(writer io.Writer) error {
+func (g *Generator) generatePDF(ctx context.Context, writer io.Writer, options GenerateOptions) error {
+	switch options.PDFA {
+	case "", PDFA2B, PDFA3B:
+	default:
+		return fmt.Errorf("conformidade PDF/A não suportada: %q", options.PDFA)
+	}
+
+	opts := append(g.rendererOptions(options), renderer.WithSourceXML(g.xml), renderer.WithPDFA(options.PDFA))
+	pdfRenderer := renderer.NewPDFRenderer(g.nfe, opts...)
+	if g.pdfBackend == PDFNative {
+		if g.template != nil {
+			return fmt.Errorf("erro ao gerar PDF: %w; use WithConverter", ErrTemplateNativePDF)
+		}
+		return pdfRenderer.RenderToWriter(writer)
+	}
+
 	// Primeiro gerar HTML em memória
-	htmlRenderer := renderer.NewHTMLRenderer(g.nfe)
+	htmlRenderer := renderer.NewHTMLRenderer(g.nfe, g.rendererOptions(options)...)
 	
 	// Renderizar HTML para buffer
 	var htmlBuffer bytes.Buffer
@@ -91,11 +236,19 @@ func (g *Generator) generatePDF(writer io.Writer) error {
 		return fmt.Errorf("erro ao renderizar HTML: %w", err)
 	}
 	
-	// Usar o conversor PDF
-	pdfConverter := converter.NewPDFConverter()
-	pdfBytes, err := pdfConverter.ConvertHTMLToPDF(htmlBuffer.Bytes())
+	// Usar o conversor PDF configurado
+	if g.converter == nil {
+		return fmt.Errorf("%w: %w", ErrConversion, ErrNoConverter)
+	}
+	paper, _ := options.Paper.profile()
+	pdfBytes, err := g.converter.Convert(ctx, htmlBuffer.Bytes(), converter.PageSettings{
+		WidthMM:  paper.PageWidthMM,
+		HeightMM: paper.PageHeightMM,
+		MarginMM: paper.PageMarginMM,
+		Document: pdfRenderer.Document(),
+	})
 	if err != nil {
-		return fmt.Errorf("erro ao converter para PDF: %w", err)
+		return fmt.Errorf("%w: %w", ErrConversion, err)
 	}
 	
 	// Escrever PDF no writer
@@ -103,11 +256,98 @@ func (g *Generator) generatePDF(writer io.Writer) error {
 	return err
 }
 
+// generateJSON serializa a NF-e em formato JSON
+func (g *Generator) generateJSON(writer io.Writer, options GenerateOptions) error {
+	jsonRenderer := renderer.NewJSONRenderer(g.nfe, g.rendererOptions(options)...)
+	return jsonRenderer.RenderToWriter(writer)
+}
+
+// generateText gera o DANFE em texto monoespaçado
+func (g *Generator) generateText(writer io.Writer, options GenerateOptions) error {
+	opts := append(g.rendererOptions(options), renderer.WithColumns(options.Columns))
+	textRenderer := renderer.NewTextRenderer(g.nfe, opts...)
+	return textRenderer.RenderToWriter(writer)
+}
+
+// generateESCPOS gera o DANFE como comandos ESC/POS
+func (g *Generator) generateESCPOS(writer io.Writer, options GenerateOptions) error {
+	printer, err := options.Printer.profile()
+	if err != nil {
+		return err
+	}
+	opts := append(g.rendererOptions(options), renderer.WithColumns(options.Columns), renderer.WithPrinter(printer))
+	escposRenderer := renderer.NewESCPOSRenderer(g.nfe, opts...)
+	return escposRenderer.RenderToWriter(writer)
+}
+
+// generateImage desenha o DANFE como imagem, sem passar pelo HTML
+func (g *Generator) generateImage(writer io.Writer, options GenerateOptions) error {
+	opts := append(g.rendererOptions(options),
+		renderer.WithColumns(options.Columns),
+		renderer.WithRaster(renderer.RasterOptions{DPI: options.DPI, Dots: options.Dots}),
+	)
+	rasterRenderer := renderer.NewRasterRenderer(g.nfe, opts...)
+	switch options.Format {
+	case FormatBMP:
+		return rasterRenderer.RenderBMP(writer)
+	case FormatPBM:
+		return rasterRenderer.RenderPBM(writer)
+	default:
+		return rasterRenderer.RenderPNG(writer)
+	}
+}
+
+// EmailRenderer retorna o renderizador do DANFE para corpo de e-mail com as
+// opções do gerador, aplicando a política de situação da autorização
+func (g *Generator) EmailRenderer(options GenerateOptions) (*renderer.EmailRenderer, error) {
+	if err := g.checkStatus(); err != nil {
+		return nil, err
+	}
+	return renderer.NewEmailRenderer(g.nfe, g.rendererOptions(options)...), nil
+}
+
+// rendererOptions monta as opções do renderizador a partir da configuração do gerador
+func (g *Generator) rendererOptions(options GenerateOptions) []renderer.Option {
+	paper, _ := options.Paper.profile()
+	return []renderer.Option{
+		renderer.WithPaper(paper),
+		renderer.WithVias(g.vias(options.Copies)...),
+		renderer.WithLocation(g.location()),
+		renderer.WithCancelamento(g.GetCancelamento()),
+		renderer.WithStatusBanner(g.statusBanner()),
+		renderer.WithHomologacao(g.forceHomologacao),
+		renderer.WithPendenteAutorizacao(g.IsPendenteAutorizacao()),
+		renderer.WithTemplate(g.template),
+		renderer.WithLogo(g.logo),
+		renderer.WithQRCode(g.qrcode),
+	}
+}
+
+// vias retorna os rótulos das vias a serem geradas
+func (g *Generator) vias(mode CopiesMode) []string {
+	switch {
+	case mode == CopiesBoth, mode == CopiesAuto && g.nfe.IsContingenciaOffline():
+		return []string{renderer.ViaConsumidor, renderer.ViaEstabelecimento}
+	default:
+		return nil
+	}
+}
+
+// IsHomologacao verifica se o DANFE recebe as marcações de ambiente de homologação
+func (g *Generator) IsHomologacao() bool {
+	return g.forceHomologacao || g.nfe.IsHomologacao()
+}
+
 // GetNFe retorna a estrutura NFeProc parseada
 func (g *Generator) GetNFe() *xmlparser.NFeProc {
 	return g.nfe
 }
 
+// GetXML retorna o XML original da NF-e
+func (g *Generator) GetXML() []byte {
+	return g.xml
+}
+
 // IsNFCe verifica se é uma NFC-e
 func (g *Generator) IsNFCe() bool {
 	return g.nfe.IsNFCe()
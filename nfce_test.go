@@ -0,0 +1,120 @@
+package nfce
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/marcelo-cunha/nfce-render/converter"
+	"github.com/marcelo-cunha/nfce-render/renderer"
+)
+
+func TestGeneratePDFWithConverter(t *testing.T) {
+	xmlContent, err := os.ReadFile("testdata/nfce.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &converter.FakeConverter{}
+	g, err := NewGenerator(xmlContent, WithConverter(fake))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = g.GenerateToWriterContext(context.Background(), &buf, GenerateOptions{Format: FormatPDF, Paper: PaperA4, PDFA: PDFA3B})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), converter.FakePDF) {
+		t.Errorf("PDF do conversor não repassado: %q", buf.Bytes())
+	}
+
+	calls := fake.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("esperada 1 chamada ao conversor, obtidas %d", len(calls))
+	}
+	call := calls[0]
+	if !bytes.Contains(call.HTML, []byte("size: 210mm 297mm")) || !bytes.Contains(call.HTML, []byte("LOJA EXEMPLO LTDA")) {
+		t.Error("HTML do DANFE em A4 não enviado ao conversor")
+	}
+	if call.Page.WidthMM != 210 || call.Page.HeightMM != 297 || call.Page.MarginMM != 10 {
+		t.Errorf("página %+v, esperada A4 com margem de 10mm", call.Page)
+	}
+	doc := call.Page.Document
+	if doc.PDFA != converter.PDFA3B || !strings.Contains(doc.Metadata.Title, "NFC-e nº 123") {
+		t.Errorf("documento %+v", doc)
+	}
+	if len(doc.Attachments) != 1 || !bytes.Equal(doc.Attachments[0].Data, xmlContent) {
+		t.Error("XML original não embutido no PDF/A-3b")
+	}
+}
+
+func TestGeneratePDFConverterError(t *testing.T) {
+	xmlContent, err := os.ReadFile("testdata/nfce.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &converter.FakeConverter{Err: converter.ErrNoURL}
+	g, err := NewGenerator(xmlContent, WithConverter(fake))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var buf bytes.Buffer
+	if err := g.GenerateToWriterContext(ctx, &buf, GenerateOptions{Format: FormatPDF}); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelamento não repassado ao conversor: %v", err)
+	}
+	if err := g.GenerateToWriter(&buf, GenerateOptions{Format: FormatPDF}); !errors.Is(err, converter.ErrNoURL) {
+		t.Errorf("erro do conversor não repassado: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("%d bytes escritos apesar do erro", buf.Len())
+	}
+}
+
+func TestGeneratePDFWithTemplate(t *testing.T) {
+	xmlContent, err := os.ReadFile("testdata/nfce.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := renderer.ParseTemplate(`{{define "footer"}}<p>rodapé personalizado</p>{{end}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := GenerateOptions{Format: FormatPDF}
+
+	// Sem conversor, o template não é ignorado em silêncio
+	g, err := NewGenerator(xmlContent, WithTemplate(tmpl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.GenerateToWriter(io.Discard, options); !errors.Is(err, ErrNoConverter) {
+		t.Errorf("esperado ErrNoConverter, obtido %v", err)
+	}
+
+	g, err = NewGenerator(xmlContent, WithTemplate(tmpl), WithPDFBackend(PDFNative))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.GenerateToWriter(io.Discard, options); !errors.Is(err, ErrTemplateNativePDF) {
+		t.Errorf("esperado ErrTemplateNativePDF, obtido %v", err)
+	}
+
+	fake := &converter.FakeConverter{}
+	g, err = NewGenerator(xmlContent, WithConverter(fake), WithTemplate(tmpl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.GenerateToWriter(io.Discard, options); err != nil {
+		t.Fatal(err)
+	}
+	if calls := fake.Calls(); len(calls) != 1 || !bytes.Contains(calls[0].HTML, []byte("rodapé personalizado")) {
+		t.Error("template personalizado não enviado ao conversor")
+	}
+}
@@ -0,0 +1,148 @@
+package nfce
+
+import (
+	"time"
+
+	"github.com/marcelo-cunha/nfce-render/converter"
+	"github.com/marcelo-cunha/nfce-render/renderer"
+	"github.com/marcelo-cunha/nfce-render/xmlparser"
+)
+
+// Option configura o Generator
+type Option func(*Generator)
+
+// TimezoneMode define como o fuso horário das datas exibidas é escolhido
+type TimezoneMode int
+
+const (
+	// TimezoneDocument usa o fuso informado na data de emissão (dhEmi)
+	TimezoneDocument TimezoneMode = iota
+	// TimezoneFixed usa sempre o fuso definido em TimezonePolicy.Location
+	TimezoneFixed
+	// TimezoneUF usa o fuso da UF do emitente
+	TimezoneUF
+)
+
+// TimezonePolicy define o fuso horário em que as datas do DANFE são exibidas
+type TimezonePolicy struct {
+	Mode     TimezoneMode
+	Location *time.Location // usado apenas com TimezoneFixed
+}
+
+// WithTimezone define a política de fuso horário do gerador
+func WithTimezone(policy TimezonePolicy) Option {
+	return func(g *Generator) {
+		g.timezone = policy
+	}
+}
+
+// location retorna o fuso horário em que as datas devem ser exibidas
+func (g *Generator) location() *time.Location {
+	switch g.timezone.Mode {
+	case TimezoneFixed:
+		if g.timezone.Location != nil {
+			return g.timezone.Location
+		}
+		return time.UTC
+	case TimezoneUF:
+		return xmlparser.LocationForUF(g.nfe.GetUF())
+	default:
+		// A data de emissão está no horário local do emitente; o protocolo
+		// da SEFAZ é convertido para o mesmo fuso
+		return g.nfe.GetDataEmissao().Location()
+	}
+}
+
+// StatusPolicy define o que fazer com NF-e que não foram autorizadas
+type StatusPolicy int
+
+const (
+	// StatusPolicyRefuse recusa a geração com *NotAuthorizedError (padrão)
+	StatusPolicyRefuse StatusPolicy = iota
+	// StatusPolicyBanner gera o DANFE com a faixa "DENEGADA" ou "NÃO AUTORIZADA"
+	StatusPolicyBanner
+	// StatusPolicyIgnore gera o DANFE normalmente
+	StatusPolicyIgnore
+)
+
+// WithStatusPolicy define a política para NF-e denegadas, rejeitadas ou sem protocolo
+func WithStatusPolicy(policy StatusPolicy) Option {
+	return func(g *Generator) {
+		g.statusPolicy = policy
+	}
+}
+
+// WithForceHomologacao aplica as marcações de ambiente de homologação mesmo
+// em NF-e de produção (tpAmb 1). Útil para impressões de teste.
+func WithForceHomologacao(force bool) Option {
+	return func(g *Generator) {
+		g.forceHomologacao = force
+	}
+}
+
+// WithTemplate usa um template HTML personalizado, obtido com
+// renderer.ParseTemplate ou renderer.ParseTemplateFS. O mesmo template pode
+// ser compartilhado entre geradores. O PDF passa a usar o backend PDFHTML,
+// que exige um conversor configurado com WithConverter.
+func WithTemplate(t *renderer.Template) Option {
+	return func(g *Generator) {
+		g.template = t
+		g.pdfBackend = PDFHTML
+	}
+}
+
+// WithLogo exibe o logotipo do estabelecimento no cabeçalho do DANFE. Use
+// renderer.LoadLogo ou renderer.LoadLogoFS para preparar a imagem.
+func WithLogo(logo *renderer.Logo) Option {
+	return func(g *Generator) {
+		g.logo = logo
+	}
+}
+
+// WithQRCode define o nível de correção de erro, a margem, o tamanho e o
+// formato (SVG ou PNG) do QR Code no DANFE
+func WithQRCode(opts renderer.QRCodeOptions) Option {
+	return func(g *Generator) {
+		g.qrcode = opts
+	}
+}
+
+// PDFBackend define como o DANFE em PDF é gerado
+type PDFBackend int
+
+const (
+	// PDFNative escreve o PDF diretamente em Go, sem serviços externos
+	// (padrão sem WithConverter e WithTemplate). Não usa templates: com
+	// WithTemplate a geração falha com ErrTemplateNativePDF.
+	PDFNative PDFBackend = iota
+	// PDFHTML converte o DANFE em HTML pelo Converter configurado em
+	// WithConverter; sem conversor a geração falha com ErrNoConverter.
+	// WithConverter e WithTemplate selecionam este backend.
+	PDFHTML
+)
+
+// PDFA é o nível de conformidade PDF/A do formato PDF
+type PDFA = converter.PDFA
+
+const (
+	PDFA2B = converter.PDFA2B // arquivamento
+	PDFA3B = converter.PDFA3B // arquivamento com o XML original embutido
+)
+
+// WithPDFBackend define o backend usado no formato PDF
+func WithPDFBackend(backend PDFBackend) Option {
+	return func(g *Generator) {
+		g.pdfBackend = backend
+	}
+}
+
+// WithConverter define o conversor de HTML para PDF e seleciona o backend
+// PDFHTML. Use converter.NewWkhtmltopdfConverter ou
+// converter.NewChromiumConverter para converter localmente e
+// converter.FakeConverter em testes.
+func WithConverter(c converter.Converter) Option {
+	return func(g *Generator) {
+		g.converter = c
+		g.pdfBackend = PDFHTML
+	}
+}
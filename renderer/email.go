@@ -0,0 +1,182 @@
+package renderer
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+
+	"github.com/marcelo-cunha/nfce-render/xmlparser"
+)
+
+// Content-IDs das imagens referenciadas pelo HTML de e-mail. As imagens são
+// anexadas à mensagem como partes inline (multipart/related).
+const (
+	EmailQRCodeCID = "qrcode@nfce"
+	EmailLogoCID   = "logo@nfce"
+)
+
+// EmailRenderer gera o DANFE em HTML para o corpo de e-mails: layout em
+// tabelas, estilos inline e imagens referenciadas por Content-ID, já que os
+// clientes de e-mail ignoram folhas de estilo e bloqueiam data URIs
+type EmailRenderer struct {
+	config
+}
+
+// NewEmailRenderer cria uma nova instância do renderizador de e-mail
+func NewEmailRenderer(nfe *xmlparser.NFeProc, opts ...Option) *EmailRenderer {
+	return &EmailRenderer{
+		config: newConfig(nfe, opts),
+	}
+}
+
+// emailData contém os dados do template de e-mail
+type emailData struct {
+	danfeData
+	QRCodeSrc template.URL
+	LogoSrc   template.URL
+}
+
+// RenderToWriter renderiza o HTML do e-mail para um io.Writer
+func (r *EmailRenderer) RenderToWriter(writer io.Writer) error {
+	data := emailData{danfeData: pageDataFor(&r.config).Copias[0]}
+	data.Via = ""
+	if r.nfe.GetQRCode() != "" {
+		data.QRCodeSrc = "cid:" + EmailQRCodeCID
+	}
+	if r.EmailLogo() != nil {
+		data.LogoSrc = "cid:" + EmailLogoCID
+	}
+
+	if err := emailTemplate.Execute(writer, data); err != nil {
+		return fmt.Errorf("erro ao executar template: %w", err)
+	}
+	return nil
+}
+
+// QRCodePNG retorna a imagem do QR Code referenciada por EmailQRCodeCID, ou
+// nil quando a NFC-e não tem QR Code
+func (r *EmailRenderer) QRCodePNG() ([]byte, error) {
+	content := r.nfe.GetQRCode()
+	if content == "" {
+		return nil, nil
+	}
+	return qrCodePNG(content, r.qrcode)
+}
+
+// EmailLogo retorna o logotipo referenciado por EmailLogoCID. Logotipos SVG
+// não são exibidos pela maioria dos clientes de e-mail e são omitidos.
+func (r *EmailRenderer) EmailLogo() *Logo {
+	if r.logo == nil || r.logo.mimeType == LogoSVG {
+		return nil
+	}
+	return r.logo
+}
+
+// emailTemplate é o DANFE em HTML compatível com clientes de e-mail
+var emailTemplate = template.Must(template.New("email").Funcs(templateFuncs).Parse(`<!DOCTYPE html>
+<html lang="pt-br">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>DANFE NFC-e</title>
+</head>
+<body style="margin: 0; padding: 0; background-color: #f4f4f4;">
+{{- $inf := .NFe.NFe.InfNFe}}
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" border="0" style="background-color: #f4f4f4;">
+<tr><td align="center" style="padding: 16px;">
+<table role="presentation" width="420" cellpadding="0" cellspacing="0" border="0" style="width: 420px; max-width: 100%; background-color: #ffffff; font-family: Arial, Helvetica, sans-serif; font-size: 13px; color: #000000;">
+<tr><td align="center" style="padding: 16px 16px 8px;">
+{{- if .LogoSrc}}
+<img src="{{.LogoSrc}}" alt="Logo" style="display: block; max-width: 200px; max-height: 80px; margin-bottom: 8px;">
+{{- end}}
+<div style="font-size: 15px; font-weight: bold;">{{$inf.Emit.XNome}}</div>
+<div>CNPJ: {{formatCNPJ $inf.Emit.CNPJ}}</div>
+<div style="font-size: 12px;">{{$inf.Emit.EnderEmit.XLgr}}, {{$inf.Emit.EnderEmit.Nro}} - {{$inf.Emit.EnderEmit.XBairro}}, {{$inf.Emit.EnderEmit.XMun}}-{{$inf.Emit.EnderEmit.UF}} - CEP: {{formatCEP $inf.Emit.EnderEmit.CEP}}</div>
+<div style="font-weight: bold; margin-top: 8px;">DANFE NFC-e</div>
+<div style="font-size: 12px;">Documento Auxiliar da Nota Fiscal de Consumidor Eletrônica</div>
+</td></tr>
+{{- if .Homologacao}}
+<tr><td align="center" style="padding: 8px 16px; font-weight: bold; color: #cc0000;">{{.MensagemHomologacao}}</td></tr>
+{{- end}}
+{{- if .NFe.IsContingenciaOffline}}
+<tr><td align="center" style="padding: 8px 16px; border: 1px solid #000000;">
+<div style="font-weight: bold;">EMITIDA EM CONTINGÊNCIA</div>
+{{- if .PendenteAutorizacao}}<div>Pendente de autorização</div>{{end}}
+{{- with $inf.Ide.DHCont}}<div>Entrada em contingência: {{$.FormatDate .}}</div>{{end}}
+{{- with $inf.Ide.XJust}}<div>Justificativa: {{.}}</div>{{end}}
+</td></tr>
+{{- end}}
+{{- if .StatusBanner}}
+<tr><td align="center" style="padding: 8px 16px; font-weight: bold; color: #cc0000;">{{.StatusBanner}}{{if .NFe.ProtNFe.InfProt.CStat}}<div style="font-weight: normal; font-size: 12px;">{{.NFe.ProtNFe.InfProt.CStat}} - {{.NFe.ProtNFe.InfProt.XMotivo}}</div>{{end}}</td></tr>
+{{- end}}
+{{- if .Cancelamento}}
+<tr><td align="center" style="padding: 8px 16px; color: #cc0000;">
+<div style="font-weight: bold; font-size: 15px;">NFC-e CANCELADA</div>
+<div>Protocolo de cancelamento: {{.Cancelamento.GetProtocolo}}</div>
+<div>Data: {{.FormatDate .Cancelamento.GetDataRegistro}}</div>
+<div>Justificativa: {{.Cancelamento.GetJustificativa}}</div>
+</td></tr>
+{{- end}}
+<tr><td style="padding: 8px 16px;">
+<table role="presentation" width="100%" cellpadding="4" cellspacing="0" border="0" style="border-top: 1px solid #000000; border-bottom: 1px solid #000000; font-size: 12px;">
+<tr style="font-weight: bold;"><td>#</td><td>Descrição</td><td align="right">Qtd</td><td align="right">Vl unit</td><td align="right">Vl total</td></tr>
+{{- range $index, $item := $inf.Det}}
+<tr><td valign="top">{{printf "%02d" (add $index 1)}}</td><td valign="top">{{$item.Prod.CProd}} - {{$.ItemDescription $index $item}}</td><td align="right" valign="top">{{formatQuantity $item.Prod.QCom}} {{$item.Prod.UCom}}</td><td align="right" valign="top">{{formatCurrency $item.Prod.VUnCom}}</td><td align="right" valign="top">{{formatCurrency $item.Prod.VProd}}</td></tr>
+{{- end}}
+</table>
+</td></tr>
+<tr><td style="padding: 0 16px 8px;">
+<table role="presentation" width="100%" cellpadding="2" cellspacing="0" border="0">
+<tr><td>Qtde. total de itens</td><td align="right">{{len $inf.Det}}</td></tr>
+<tr><td>Valor total</td><td align="right">{{formatCurrency $inf.Total.ICMSTot.VProd}}</td></tr>
+{{- if gt $inf.Total.ICMSTot.VDesc 0.0}}
+<tr><td>Desconto</td><td align="right">{{formatCurrency $inf.Total.ICMSTot.VDesc}}</td></tr>
+{{- end}}
+{{- if gt $inf.Total.ICMSTot.VOutro 0.0}}
+<tr><td>Outros valores</td><td align="right">{{formatCurrency $inf.Total.ICMSTot.VOutro}}</td></tr>
+{{- end}}
+<tr style="font-weight: bold;"><td>Valor a pagar</td><td align="right">{{formatCurrency $inf.Total.ICMSTot.VNF}}</td></tr>
+<tr style="font-weight: bold;"><td style="padding-top: 8px;">Forma de pagamento</td><td align="right" style="padding-top: 8px;">Valor pago</td></tr>
+{{- range $inf.Pag.DetPag}}
+<tr><td>{{getPaymentMethod .TPag}}</td><td align="right">{{formatCurrency .VPag}}</td></tr>
+{{- end}}
+{{- if gt $inf.Pag.VTroco 0.0}}
+<tr><td>Troco</td><td align="right">{{formatCurrency $inf.Pag.VTroco}}</td></tr>
+{{- end}}
+</table>
+</td></tr>
+<tr><td align="center" style="padding: 8px 16px; border-top: 1px solid #000000;">
+{{- with .NFe.NFe.InfNFeSupl}}{{if .UrlChave}}
+<div style="font-weight: bold;">Consulte pela Chave de Acesso em</div>
+<div>{{.UrlChave}}</div>
+{{- end}}{{end}}
+<div style="font-family: 'Courier New', monospace; margin-top: 4px;">{{formatKey .NFe.GetChaveAcesso}}</div>
+</td></tr>
+<tr><td align="center" style="padding: 8px 16px; border-top: 1px solid #000000;">
+{{- if $inf.Dest}}
+<div style="font-weight: bold;">CONSUMIDOR{{with $inf.Dest.CPF}} - CPF {{formatCPF .}}{{end}}{{with $inf.Dest.CNPJ}} - CNPJ {{formatCNPJ .}}{{end}}</div>
+{{- with .ConsumerName}}<div>{{.}}</div>{{end}}
+{{- else}}
+<div style="font-weight: bold;">CONSUMIDOR NÃO IDENTIFICADO</div>
+{{- end}}
+</td></tr>
+<tr><td align="center" style="padding: 8px 16px; border-top: 1px solid #000000;">
+<div style="font-weight: bold;">NFC-e nº {{$inf.Ide.NNF}} Série {{$inf.Ide.Serie}} {{.FormatDate $inf.Ide.DHEmi}}</div>
+{{- if .NFe.ProtNFe.InfProt.NProt}}
+<div>Protocolo de autorização: {{.NFe.ProtNFe.InfProt.NProt}}</div>
+<div>Data de autorização: {{.FormatDate .NFe.ProtNFe.InfProt.DhRecbto}}</div>
+{{- end}}
+{{- if .QRCodeSrc}}
+<a href="{{.NFe.GetQRCode}}"><img src="{{.QRCodeSrc}}" alt="QR Code" width="160" height="160" style="display: block; margin: 8px auto; width: 160px; height: 160px;"></a>
+<div style="font-size: 12px;">Consulta via leitor de QR Code</div>
+{{- end}}
+</td></tr>
+{{- with $inf.InfAdic}}{{if .InfCpl}}
+<tr><td style="padding: 8px 16px; border-top: 1px solid #000000; font-size: 12px;"><strong>Informações de interesse do contribuinte:</strong><br>{{.InfCpl}}</td></tr>
+{{- end}}{{end}}
+</table>
+</td></tr>
+</table>
+</body>
+</html>
+`))
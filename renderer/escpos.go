@@ -0,0 +1,284 @@
+package renderer
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/marcelo-cunha/nfce-render/xmlparser"
+)
+
+// Comandos ESC/POS
+const (
+	esc = 0x1B
+	gs  = 0x1D
+	lf  = 0x0A
+)
+
+// CodePage é a tabela de caracteres usada para imprimir os acentos
+type CodePage int
+
+const (
+	CodePagePC850 CodePage = iota // multilíngue
+	CodePagePC860                 // português
+)
+
+// QRCodeCommand identifica o comando de impressão de QR Code da impressora
+type QRCodeCommand int
+
+const (
+	// QRCodeGSk é o comando GS ( k do padrão Epson
+	QRCodeGSk QRCodeCommand = iota
+	// QRCodeBematech é o comando GS k Q das impressoras Bematech
+	QRCodeBematech
+	// QRCodeDaruma é o comando ESC 0x81 das impressoras Daruma
+	QRCodeDaruma
+)
+
+// PrinterProfile define as variações de comandos de cada fabricante
+type PrinterProfile struct {
+	Name string
+
+	// CodePage é a tabela de caracteres e CodePageNumber o parâmetro do
+	// comando ESC t que a seleciona
+	CodePage       CodePage
+	CodePageNumber byte
+
+	// QRCode é o comando de QR Code e QRModuleSize o tamanho do módulo em pontos
+	QRCode       QRCodeCommand
+	QRModuleSize byte
+
+	// Cut é o comando de corte parcial do papel
+	Cut []byte
+}
+
+// Perfis de impressora suportados
+var (
+	// PrinterEpson atende as impressoras Epson TM-T20 e TM-T88 e compatíveis
+	PrinterEpson = PrinterProfile{
+		Name:           "epson",
+		CodePage:       CodePagePC860,
+		CodePageNumber: 3,
+		QRCode:         QRCodeGSk,
+		QRModuleSize:   4,
+		Cut:            []byte{gs, 'V', 66, 3},
+	}
+
+	// PrinterBematech atende as impressoras Bematech MP-4200 TH em modo ESC/POS
+	PrinterBematech = PrinterProfile{
+		Name:           "bematech",
+		CodePage:       CodePagePC850,
+		CodePageNumber: 2,
+		QRCode:         QRCodeBematech,
+		QRModuleSize:   4,
+		Cut:            []byte{esc, 'm'},
+	}
+
+	// PrinterElgin atende as impressoras Elgin i7 e i9
+	PrinterElgin = PrinterProfile{
+		Name:           "elgin",
+		CodePage:       CodePagePC850,
+		CodePageNumber: 2,
+		QRCode:         QRCodeGSk,
+		QRModuleSize:   4,
+		Cut:            []byte{gs, 'V', 66, 3},
+	}
+
+	// PrinterDaruma atende as impressoras Daruma DR700 e DR800
+	PrinterDaruma = PrinterProfile{
+		Name:           "daruma",
+		CodePage:       CodePagePC850,
+		CodePageNumber: 2,
+		QRCode:         QRCodeDaruma,
+		QRModuleSize:   4,
+		Cut:            []byte{esc, 'm'},
+	}
+)
+
+// ESCPOSRenderer gera o DANFE como sequência de comandos ESC/POS
+type ESCPOSRenderer struct {
+	config
+}
+
+// NewESCPOSRenderer cria uma nova instância do renderizador ESC/POS
+func NewESCPOSRenderer(nfe *xmlparser.NFeProc, opts ...Option) *ESCPOSRenderer {
+	return &ESCPOSRenderer{
+		config: newConfig(nfe, opts),
+	}
+}
+
+// RenderToWriter escreve os comandos de impressão do DANFE no io.Writer
+func (r *ESCPOSRenderer) RenderToWriter(writer io.Writer) error {
+	p := r.printer
+	if p.Name == "" {
+		p = PrinterEpson
+	}
+
+	var logo []byte
+	if r.logo != nil {
+		var err error
+		if logo, err = rasterCommand(r.logo, r.printerDots()); err != nil {
+			return err
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.Write([]byte{esc, '@', esc, 't', p.CodePageNumber})
+
+	for _, line := range r.receipt(r.textColumns()) {
+		buf.Write([]byte{esc, 'a', byte(line.align)})
+		switch line.kind {
+		case lineQRCode:
+			writeQRCode(&buf, p, line.text)
+		case lineLogo:
+			buf.Write(logo)
+		case lineCut:
+			buf.Write([]byte{esc, 'd', 4})
+			buf.Write(p.Cut)
+		default:
+			var mode byte
+			if line.bold {
+				mode |= 0x08
+			}
+			if line.large {
+				mode |= 0x10
+			}
+			buf.Write([]byte{esc, '!', mode})
+			buf.Write(p.CodePage.encode(line.text))
+			buf.WriteByte(lf)
+		}
+	}
+
+	buf.Write([]byte{esc, '!', 0, esc, 'a', 0, esc, 'd', 4})
+	buf.Write(p.Cut)
+
+	_, err := writer.Write(buf.Bytes())
+	return err
+}
+
+// printerDots retorna a largura de impressão em pontos (8 pontos por mm)
+func (c *config) printerDots() int {
+	if c.paper.ContentWidthMM > 0 && c.paper.ContentWidthMM < 70 {
+		return 384
+	}
+	return 576
+}
+
+// writeQRCode escreve o QR Code com o comando nativo da impressora
+func writeQRCode(buf *bytes.Buffer, p PrinterProfile, content string) {
+	data := []byte(content)
+	size := p.QRModuleSize
+	if size == 0 {
+		size = 4
+	}
+
+	switch p.QRCode {
+	case QRCodeBematech:
+		n := len(data)
+		buf.Write([]byte{gs, 'k', 'Q', 2, size, 0, 1, byte(n), byte(n >> 8)})
+		buf.Write(data)
+	case QRCodeDaruma:
+		n := len(data) + 2
+		buf.Write([]byte{esc, 0x81, byte(n), byte(n >> 8), size, 'M'})
+		buf.Write(data)
+	default:
+		n := len(data) + 3
+		buf.Write([]byte{gs, '(', 'k', 4, 0, '1', 'A', '2', 0}) // modelo 2
+		buf.Write([]byte{gs, '(', 'k', 3, 0, '1', 'C', size})   // tamanho do módulo
+		buf.Write([]byte{gs, '(', 'k', 3, 0, '1', 'E', '1'})    // correção de erro M
+		buf.Write([]byte{gs, '(', 'k', byte(n), byte(n >> 8), '1', 'P', '0'})
+		buf.Write(data)
+		buf.Write([]byte{gs, '(', 'k', 3, 0, '1', 'Q', '0'}) // imprime
+	}
+	buf.WriteByte(lf)
+}
+
+// rasterCommand converte o logotipo para o comando GS v 0, em faixas de até
+// 128 linhas para não estourar o buffer das impressoras. Logotipos SVG não
+// são impressos.
+func rasterCommand(logo *Logo, dots int) ([]byte, error) {
+	if logo.mimeType != LogoPNG {
+		return nil, nil
+	}
+	img, err := png.Decode(bytes.NewReader(logo.data))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao decodificar logotipo: %w", err)
+	}
+	if b := img.Bounds(); b.Dx() > dots {
+		h := max(1, int(math.Round(float64(b.Dy())*float64(dots)/float64(b.Dx()))))
+		img = resize(img, dots, h)
+	}
+	mono := dither(img)
+
+	w, h := mono.Bounds().Dx(), mono.Bounds().Dy()
+	widthBytes := (w + 7) / 8
+
+	var buf bytes.Buffer
+	for y0 := 0; y0 < h; y0 += 128 {
+		rows := min(128, h-y0)
+		buf.Write([]byte{gs, 'v', '0', 0, byte(widthBytes), byte(widthBytes >> 8), byte(rows), byte(rows >> 8)})
+		for y := y0; y < y0+rows; y++ {
+			row := make([]byte, widthBytes)
+			for x := 0; x < w; x++ {
+				if mono.ColorIndexAt(x, y) == 0 { // preto
+					row[x/8] |= 0x80 >> (x % 8)
+				}
+			}
+			buf.Write(row)
+		}
+	}
+	buf.WriteByte(lf)
+	return buf.Bytes(), nil
+}
+
+// encode converte o texto para a tabela de caracteres. Caracteres sem
+// representação são trocados pela letra sem acento ou por "?".
+func (cp CodePage) encode(s string) []byte {
+	table := pc850
+	if cp == CodePagePC860 {
+		table = pc860
+	}
+
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r < 0x80:
+			out = append(out, byte(r))
+		case table[r] != 0:
+			out = append(out, table[r])
+		case fallback[r] != 0:
+			out = append(out, fallback[r])
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
+
+// pc860 mapeia os caracteres acentuados para a página de código 860 (português)
+var pc860 = map[rune]byte{
+	'Ç': 0x80, 'ü': 0x81, 'é': 0x82, 'â': 0x83, 'ã': 0x84, 'à': 0x85, 'Á': 0x86, 'ç': 0x87,
+	'ê': 0x88, 'Ê': 0x89, 'è': 0x8A, 'Í': 0x8B, 'Ô': 0x8C, 'ì': 0x8D, 'Ã': 0x8E, 'Â': 0x8F,
+	'É': 0x90, 'À': 0x91, 'È': 0x92, 'ô': 0x93, 'õ': 0x94, 'ò': 0x95, 'Ú': 0x96, 'ù': 0x97,
+	'Ì': 0x98, 'Õ': 0x99, 'Ü': 0x9A, 'Ù': 0x9D, 'Ó': 0x9F, 'á': 0xA0, 'í': 0xA1, 'ó': 0xA2,
+	'ú': 0xA3, 'ñ': 0xA4, 'Ñ': 0xA5, 'ª': 0xA6, 'º': 0xA7, 'Ò': 0xA9, '°': 0xF8,
+}
+
+// pc850 mapeia os caracteres acentuados para a página de código 850 (multilíngue)
+var pc850 = map[rune]byte{
+	'Ç': 0x80, 'ü': 0x81, 'é': 0x82, 'â': 0x83, 'à': 0x85, 'ç': 0x87, 'ê': 0x88, 'è': 0x8A,
+	'ì': 0x8D, 'É': 0x90, 'ô': 0x93, 'ò': 0x95, 'ù': 0x97, 'Ü': 0x9A, 'á': 0xA0, 'í': 0xA1,
+	'ó': 0xA2, 'ú': 0xA3, 'ñ': 0xA4, 'Ñ': 0xA5, 'ª': 0xA6, 'º': 0xA7, 'Á': 0xB5, 'Â': 0xB6,
+	'À': 0xB7, 'ã': 0xC6, 'Ã': 0xC7, 'Ê': 0xD2, 'È': 0xD4, 'Í': 0xD6, 'Ì': 0xDE, 'Ó': 0xE0,
+	'Ô': 0xE2, 'Ò': 0xE3, 'õ': 0xE4, 'Õ': 0xE5, 'Ú': 0xE9, 'Ù': 0xEB, '°': 0xF8,
+}
+
+// fallback substitui caracteres tipográficos sem representação nas páginas
+// de código
+var fallback = map[rune]byte{
+	'–': '-', '—': '-', '‘': '\'', '’': '\'', '“': '"', '”': '"', '…': '.',
+	'ä': 'a', 'ë': 'e', 'ï': 'i', 'ö': 'o', 'Ä': 'A', 'Ë': 'E', 'Ï': 'I', 'Ö': 'O',
+	'î': 'i', 'û': 'u', 'Î': 'I', 'Û': 'U',
+}
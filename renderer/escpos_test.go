@@ -0,0 +1,145 @@
+package renderer
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+var escposProfiles = []struct {
+	profile PrinterProfile
+	init    []byte
+	qr      []byte // QR Code com o conteúdo "ABC"
+	tail    []byte
+}{
+	{
+		PrinterEpson,
+		[]byte{0x1B, '@', 0x1B, 't', 3},
+		[]byte{
+			0x1D, '(', 'k', 4, 0, '1', 'A', '2', 0,
+			0x1D, '(', 'k', 3, 0, '1', 'C', 4,
+			0x1D, '(', 'k', 3, 0, '1', 'E', '1',
+			0x1D, '(', 'k', 6, 0, '1', 'P', '0', 'A', 'B', 'C',
+			0x1D, '(', 'k', 3, 0, '1', 'Q', '0',
+			0x0A,
+		},
+		[]byte{0x1B, '!', 0, 0x1B, 'a', 0, 0x1B, 'd', 4, 0x1D, 'V', 66, 3},
+	},
+	{
+		PrinterBematech,
+		[]byte{0x1B, '@', 0x1B, 't', 2},
+		[]byte{0x1D, 'k', 'Q', 2, 4, 0, 1, 3, 0, 'A', 'B', 'C', 0x0A},
+		[]byte{0x1B, '!', 0, 0x1B, 'a', 0, 0x1B, 'd', 4, 0x1B, 'm'},
+	},
+	{
+		PrinterElgin,
+		[]byte{0x1B, '@', 0x1B, 't', 2},
+		[]byte{
+			0x1D, '(', 'k', 4, 0, '1', 'A', '2', 0,
+			0x1D, '(', 'k', 3, 0, '1', 'C', 4,
+			0x1D, '(', 'k', 3, 0, '1', 'E', '1',
+			0x1D, '(', 'k', 6, 0, '1', 'P', '0', 'A', 'B', 'C',
+			0x1D, '(', 'k', 3, 0, '1', 'Q', '0',
+			0x0A,
+		},
+		[]byte{0x1B, '!', 0, 0x1B, 'a', 0, 0x1B, 'd', 4, 0x1D, 'V', 66, 3},
+	},
+	{
+		PrinterDaruma,
+		[]byte{0x1B, '@', 0x1B, 't', 2},
+		[]byte{0x1B, 0x81, 5, 0, 4, 'M', 'A', 'B', 'C', 0x0A},
+		[]byte{0x1B, '!', 0, 0x1B, 'a', 0, 0x1B, 'd', 4, 0x1B, 'm'},
+	},
+}
+
+func TestWriteQRCode(t *testing.T) {
+	for _, tc := range escposProfiles {
+		var buf bytes.Buffer
+		writeQRCode(&buf, tc.profile, "ABC")
+		if !bytes.Equal(buf.Bytes(), tc.qr) {
+			t.Errorf("%s: QR Code\n% X\nesperado\n% X", tc.profile.Name, buf.Bytes(), tc.qr)
+		}
+	}
+}
+
+func TestESCPOSRendererProfiles(t *testing.T) {
+	nfe := sampleNFe()
+	for _, tc := range escposProfiles {
+		t.Run(tc.profile.Name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := NewESCPOSRenderer(nfe, WithPrinter(tc.profile)).RenderToWriter(&buf); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.Bytes()
+
+			if !bytes.HasPrefix(out, tc.init) {
+				t.Errorf("inicialização % X, esperada % X", out[:len(tc.init)], tc.init)
+			}
+			if !bytes.HasSuffix(out, tc.tail) {
+				t.Errorf("final % X, esperado % X", out[len(out)-len(tc.tail):], tc.tail)
+			}
+
+			var qr bytes.Buffer
+			writeQRCode(&qr, tc.profile, nfe.GetQRCode())
+			if n := bytes.Count(out, qr.Bytes()); n != 1 {
+				t.Errorf("QR Code encontrado %d vezes", n)
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := NewESCPOSRenderer(nfe).RenderToWriter(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), escposProfiles[0].init) {
+		t.Error("perfil padrão diferente da Epson")
+	}
+}
+
+func TestCodePageEncode(t *testing.T) {
+	for _, tc := range []struct {
+		cp   CodePage
+		want []byte
+	}{
+		{CodePagePC860, []byte{0x80, 0x8E, 0x87, 0x84, 0x86, 0xA0, '-', '?'}},
+		{CodePagePC850, []byte{0x80, 0xC7, 0x87, 0xC6, 0xB5, 0xA0, '-', '?'}},
+	} {
+		if got := tc.cp.encode("ÇÃçãÁá–€"); !bytes.Equal(got, tc.want) {
+			t.Errorf("página %d: % X, esperado % X", tc.cp, got, tc.want)
+		}
+	}
+}
+
+func TestRasterCommandBands(t *testing.T) {
+	// 12 x 300 pontos pretos: três faixas de 128, 128 e 44 linhas com 2 bytes
+	img := image.NewGray(image.Rect(0, 0, 12, 300))
+	img.Set(0, 0, color.White)
+	var data bytes.Buffer
+	if err := png.Encode(&data, img); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := rasterCommand(&Logo{mimeType: LogoPNG, data: data.Bytes()}, 576)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want []byte
+	for _, rows := range []int{128, 128, 44} {
+		want = append(want, 0x1D, 'v', '0', 0, 2, 0, byte(rows), 0)
+		for y := 0; y < rows; y++ {
+			want = append(want, 0xFF, 0xF0)
+		}
+	}
+	want[8] = 0x7F // ponto branco no canto
+	want = append(want, 0x0A)
+	if !bytes.Equal(out, want) {
+		t.Errorf("comando GS v 0 com %d bytes, esperados %d\n% X", len(out), len(want), out[:min(len(out), 32)])
+	}
+
+	if out, err := rasterCommand(&Logo{mimeType: LogoSVG}, 576); out != nil || err != nil {
+		t.Errorf("logotipo SVG impresso: %v", err)
+	}
+}
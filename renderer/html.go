@@ -1,101 +1,121 @@
 package renderer
 
 import (
-	"encoding/base64"
 	"fmt"
 	"html/template"
 	"io"
-	"strings"
 	"time"
 
 	"github.com/marcelo-cunha/nfce-render/xmlparser"
-	"github.com/skip2/go-qrcode"
 )
 
 // HTMLRenderer é responsável pela renderização do DANFE em HTML
 type HTMLRenderer struct {
-	nfe *xmlparser.NFeProc
+	config
+}
+
+// danfeData contém os dados de uma via do DANFE
+type danfeData struct {
+	NFe                 *xmlparser.NFeProc
+	Cancelamento        *xmlparser.ProcEventoNFe
+	StatusBanner        string
+	Homologacao         bool
+	MensagemHomologacao string
+	PendenteAutorizacao bool
+	Via                 string
+	Logo                *Logo
+
+	config *config
+}
+
+// FormatDate formata data e hora no fuso do renderizador
+func (d danfeData) FormatDate(t time.Time) string {
+	return d.config.formatDate(t)
+}
+
+// FormatDateOnly formata a data no fuso do renderizador
+func (d danfeData) FormatDateOnly(t time.Time) string {
+	return d.config.localTime(t).Format("02/01/2006")
+}
+
+// ItemDescription retorna a descrição exibida para o item
+func (d danfeData) ItemDescription(index int, det xmlparser.Det) string {
+	return d.config.itemDescription(index, det)
+}
+
+// ConsumerName retorna o nome exibido para o consumidor identificado
+func (d danfeData) ConsumerName() string {
+	return d.config.consumerName()
+}
+
+// LogoStyle retorna as dimensões máximas do logotipo em CSS para o papel
+// do renderizador
+func (d danfeData) LogoStyle() template.CSS {
+	if d.Logo == nil {
+		return ""
+	}
+	return d.Logo.style(d.config.paper.columnWidthMM())
+}
+
+// QRCode gera o QR Code em SVG inline com as opções do renderizador
+func (d danfeData) QRCode(content string) template.HTML {
+	return generateQRCodeHTML(content, d.config.qrcode, d.config.paper.QRCodeMM)
+}
+
+// pageData contém as vias que compõem o documento HTML
+type pageData struct {
+	Copias []danfeData
+	Paper  PaperProfile
 }
 
 // NewHTMLRenderer cria uma nova instância do renderizador HTML
-func NewHTMLRenderer(nfe *xmlparser.NFeProc) *HTMLRenderer {
+func NewHTMLRenderer(nfe *xmlparser.NFeProc, opts ...Option) *HTMLRenderer {
 	return &HTMLRenderer{
-		nfe: nfe,
+		config: newConfig(nfe, opts),
 	}
 }
 
 // RenderToWriter renderiza o DANFE em HTML para um io.Writer
 func (r *HTMLRenderer) RenderToWriter(writer io.Writer) error {
-	// Criar template com funções auxiliares
-	tmpl := template.New("danfe").Funcs(template.FuncMap{
-		"formatCNPJ":     xmlparser.FormatCNPJ,
-		"formatCPF":      xmlparser.FormatCPF,
-		"formatCEP":      xmlparser.FormatCEP,
-		"formatCurrency": xmlparser.FormatCurrency,
-		"formatQuantity": xmlparser.FormatQuantity,
-		"formatDate": func(t time.Time) string {
-			return t.Format("02/01/2006 15:04:05")
-		},
-		"formatDateOnly": func(t time.Time) string {
-			return t.Format("02/01/2006")
-		},
-		"getPaymentMethod": xmlparser.GetPaymentMethodDescription,
-		"generateQRCode":   r.generateQRCodeHTML,
-		"upper":            strings.ToUpper,
-		"add": func(a, b int) int {
-			return a + b
-		},
-		"formatKey": func(key string) string {
-			if len(key) == 0 {
-				return key
-			}
-			var result strings.Builder
-			for i, char := range key {
-				if i > 0 && i%4 == 0 {
-					result.WriteString(" ")
-				}
-				result.WriteRune(char)
-			}
-			return result.String()
-		},
-	})
-
-	// Parse do template
-	var err error
-	tmpl, err = tmpl.Parse(danfeTemplate)
-	if err != nil {
-		return fmt.Errorf("erro ao fazer parse do template: %w", err)
-	}
-
-	// Executar template
-	data := struct {
-		NFe *xmlparser.NFeProc
-	}{
-		NFe: r.nfe,
+	tmpl := r.template
+	if tmpl == nil {
+		var err error
+		if tmpl, err = DefaultTemplate(); err != nil {
+			return err
+		}
 	}
 
-	if err := tmpl.Execute(writer, data); err != nil {
+	if err := tmpl.execute(writer, &r.config, false); err != nil {
 		return fmt.Errorf("erro ao executar template: %w", err)
 	}
 
 	return nil
 }
 
-// generateQRCodeHTML gera um QR Code em formato HTML
-func (r *HTMLRenderer) generateQRCodeHTML(content string) template.HTML {
-	if content == "" {
-		return template.HTML("")
+// pageDataFor monta os dados de cada via do DANFE
+func pageDataFor(c *config) pageData {
+	base := danfeData{
+		NFe:                 c.nfe,
+		Cancelamento:        c.cancelamento,
+		StatusBanner:        c.statusBanner,
+		Homologacao:         c.isHomologacao(),
+		MensagemHomologacao: xmlparser.MensagemHomologacao,
+		PendenteAutorizacao: c.pendente,
+		Logo:                c.logo,
+		config:              c,
 	}
 
-	// Gerar QR Code como PNG
-	pngBytes, err := qrcode.Encode(content, qrcode.Medium, 256)
-	if err != nil {
-		return template.HTML("")
+	if len(c.vias) == 0 {
+		return pageData{Copias: []danfeData{base}, Paper: c.paper}
 	}
 
-	// Converter para base64 para embedding no HTML
-	base64String := base64.StdEncoding.EncodeToString(pngBytes)
-	return template.HTML(fmt.Sprintf(`<img src="data:image/png;base64,%s" alt="QR Code" style="width: 25mm; height: 25mm;">`, base64String))
+	copias := make([]danfeData, 0, len(c.vias))
+	for _, via := range c.vias {
+		copia := base
+		copia.Via = via
+		copias = append(copias, copia)
+	}
+	return pageData{Copias: copias, Paper: c.paper}
 }
 
 // Template HTML do DANFE
@@ -106,85 +126,179 @@ const danfeTemplate = `
     <meta charset="UTF-8">
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
     <title>DANFE NFC-e</title>
-    <style>
+    <style>{{block "styles" .}}
+        :root {
+            {{.Paper.CSSVariables}}
+        }
+        
         @page {
-            size: 80mm auto;
-            margin: 0mm;
+            size: {{.Paper.PageSize}};
+            margin: var(--page-margin);
         }
         
         @media print {
             body { 
                 margin: 0; 
                 padding: 0;
-                width: 80mm;
+                width: var(--body-width);
             }
             .danfe { 
                 box-shadow: none; 
                 border: none; 
-                width: 80mm;
-                max-width: 80mm;
+                width: var(--content-width);
+                max-width: var(--content-width);
             }
         }
         
         body {
             font-family: 'Arial', sans-serif;
-            font-size: 8px;
+            font-size: calc(8px * var(--font-scale));
             margin: 0;
             padding: 0;
             background-color: white;
             line-height: 1.2;
-            width: 80mm;
-            max-width: 80mm;
+            width: var(--body-width);
+            max-width: var(--body-width);
             min-height: auto;
         }
         
         .danfe {
-            width: 80mm;
-            max-width: 80mm;
-            margin: 0;
+            position: relative;
+            overflow: hidden;
+            width: var(--content-width);
+            max-width: var(--content-width);
+            margin: 0 auto;
             background-color: white;
-            padding: 2mm;
+            padding: var(--padding);
             box-sizing: border-box;
+            column-count: var(--columns);
+            column-gap: var(--column-gap);
             min-height: auto;
         }
         
+        .watermark {
+            position: absolute;
+            top: 35%;
+            left: -10%;
+            width: 120%;
+            text-align: center;
+            font-size: calc(40px * var(--font-scale));
+            font-weight: bold;
+            letter-spacing: 4px;
+            color: rgba(200, 0, 0, 0.3);
+            transform: rotate(-45deg);
+            z-index: 10;
+            pointer-events: none;
+        }
+        
+        .cancel-info {
+            border: 2px solid #c00;
+            color: #c00;
+            text-align: center;
+            font-size: calc(10px * var(--font-scale));
+            padding: 2px;
+            margin: 4px 0;
+        }
+        
+        .env-warning {
+            border: 1px dashed #000;
+            text-align: center;
+            font-weight: bold;
+            font-size: calc(11px * var(--font-scale));
+            padding: 2px;
+            margin: 4px 0;
+        }
+        
+        .contingency {
+            border: 1px solid #000;
+            text-align: center;
+            font-size: calc(10px * var(--font-scale));
+            padding: 2px;
+            margin: 4px 0;
+        }
+        
+        .contingency-title {
+            font-weight: bold;
+            font-size: calc(12px * var(--font-scale));
+        }
+        
+        .via {
+            font-weight: bold;
+            font-size: calc(10px * var(--font-scale));
+            text-transform: uppercase;
+        }
+        
+        .cut-mark {
+            width: var(--content-width);
+            text-align: center;
+            font-size: calc(9px * var(--font-scale));
+            color: #333;
+            margin: 4mm auto;
+        }
+        
+        .status-banner {
+            border: 2px solid #000;
+            background-color: #000;
+            color: white;
+            text-align: center;
+            font-weight: bold;
+            font-size: calc(14px * var(--font-scale));
+            padding: 2px;
+            margin: 4px 0;
+        }
+        
+        .cancel-title {
+            font-weight: bold;
+            font-size: calc(13px * var(--font-scale));
+        }
+        
         .header {
             text-align: center;
             margin-bottom: 4px;
         }
         
+        .logo {
+            margin-bottom: 8px;
+        }
+        
+        .logo img {
+            display: block;
+            margin: 0 auto;
+            max-width: 100%;
+        }
+        
         .company-name {
             font-weight: bold;
-            font-size: 14px;
+            font-size: calc(14px * var(--font-scale));
             margin-bottom: 1px;
         }
         
         .cnpj {
             font-weight: bold;
-            font-size: 12px;
+            font-size: calc(12px * var(--font-scale));
             margin-bottom: 1px;
         }
         
         .address {
-            font-size: 11px;
+            font-size: calc(11px * var(--font-scale));
             margin-bottom: 1px;
             line-height: 1.0;
         }
         
         .document-title {
             font-weight: bold;
-            font-size: 13px;
+            font-size: calc(13px * var(--font-scale));
             margin: 4px 0 2px 0;
         }
         
         .document-subtitle {
-            font-size: 10px;
+            font-size: calc(10px * var(--font-scale));
             margin-bottom: 4px;
         }
         
         .section-title {
             font-weight: bold;
-            font-size: 11px;
+            font-size: calc(11px * var(--font-scale));
             text-align: center;
             margin: 4px 0 2px 0;
             border-top: 1px dashed #333;
@@ -194,7 +308,7 @@ const danfeTemplate = `
         
         .item {
             margin-bottom: 3px;
-            font-size: 10px;
+            font-size: calc(10px * var(--font-scale));
         }
         
         .item-line {
@@ -220,7 +334,7 @@ const danfeTemplate = `
         
         .totals {
             margin-bottom: 4px;
-            font-size: 10px;
+            font-size: calc(10px * var(--font-scale));
         }
         
         .total-line {
@@ -231,14 +345,14 @@ const danfeTemplate = `
         
         .total-final {
             font-weight: bold;
-            font-size: 11px;
+            font-size: calc(11px * var(--font-scale));
             border-top: 1px solid #333;
             padding-top: 1px;
         }
         
         .payment {
             margin-bottom: 4px;
-            font-size: 10px;
+            font-size: calc(10px * var(--font-scale));
         }
         
         .payment-line {
@@ -249,23 +363,23 @@ const danfeTemplate = `
         
         .consumer {
             margin-bottom: 4px;
-            font-size: 10px;
+            font-size: calc(10px * var(--font-scale));
         }
         
         .nfc-info {
             margin-bottom: 4px;
-            font-size: 10px;
+            font-size: calc(10px * var(--font-scale));
         }
         
         .footer {
             text-align: center;
-            font-size: 10px;
+            font-size: calc(10px * var(--font-scale));
             margin-bottom: 4px;
         }
         
         .key {
             word-break: break-all;
-            font-size: 9px;
+            font-size: calc(9px * var(--font-scale));
             margin: 2px 0;
             text-align: center;
         }
@@ -276,27 +390,40 @@ const danfeTemplate = `
             margin-top: 4px;
         }
         
-        .qr-code img {
-            width: 20mm;
-            height: 20mm;
-            border: 1px solid #ddd;
+        .qr-code img, .qr-code svg {
+            width: var(--qr-size);
+            height: var(--qr-size);
+            vertical-align: top;
         }
         
         .qr-text {
-            font-size: 9px;
+            font-size: calc(9px * var(--font-scale));
             margin-top: 1px;
             color: #666;
         }
-    </style>
+    {{end}}</style>
 </head>
 <body>
+    {{range $i, $copia := .Copias}}
+    {{if $i}}<div class="cut-mark">- - - - - - - - - - corte aqui - - - - - - - - - -</div>{{end}}
+    {{template "via" $copia}}
+    {{end}}
+</body>
+</html>
+{{define "via"}}
     <div class="danfe">
+        {{if .Cancelamento}}
+        <div class="watermark">CANCELADA</div>
+        {{end}}
         
+        {{block "header" .}}
         <div class="header">
             
-            <div style="margin-bottom: 8px;">
-                <!-- Cabeçalho sem logo -->
+            {{with .Logo}}
+            <div class="logo">
+                <img src="{{.DataURI}}" alt="Logo" style="{{$.LogoStyle}}">
             </div>
+            {{end}}
             
             
             <div class="company-name">{{.NFe.NFe.InfNFe.Emit.XNome}}</div>
@@ -308,9 +435,41 @@ const danfeTemplate = `
             </div>
             <div class="document-title">DANFE NFC-e</div>
             <div class="document-subtitle">Documento Auxiliar da Nota Fiscal de Consumidor Eletrônica</div>
+            {{if .Via}}<div class="via">{{.Via}}</div>{{end}}
         </div>
+        {{end}}
+
+        {{if .Homologacao}}
+        <div class="env-warning">{{.MensagemHomologacao}}</div>
+        {{end}}
+
+        {{if .NFe.IsContingenciaOffline}}
+        <div class="contingency">
+            <div class="contingency-title">EMITIDA EM CONTINGÊNCIA</div>
+            {{if .PendenteAutorizacao}}<div>Pendente de autorização</div>{{end}}
+            {{with .NFe.NFe.InfNFe.Ide.DHCont}}Entrada em contingência: {{$.FormatDate .}}<br>{{end}}
+            {{with .NFe.NFe.InfNFe.Ide.XJust}}Justificativa: {{.}}{{end}}
+        </div>
+        {{end}}
+
+        {{if .StatusBanner}}
+        <div class="status-banner">
+            {{.StatusBanner}}
+            {{if .NFe.ProtNFe.InfProt.CStat}}<div style="font-size: 9px; font-weight: normal;">{{.NFe.ProtNFe.InfProt.CStat}} - {{.NFe.ProtNFe.InfProt.XMotivo}}</div>{{end}}
+        </div>
+        {{end}}
+
+        {{if .Cancelamento}}
+        <div class="cancel-info">
+            <div class="cancel-title">NFC-e CANCELADA</div>
+            Protocolo de cancelamento: {{.Cancelamento.GetProtocolo}}<br>
+            Data: {{.FormatDate .Cancelamento.GetDataRegistro}}<br>
+            Justificativa: {{.Cancelamento.GetJustificativa}}
+        </div>
+        {{end}}
 
         
+        {{block "items" .}}
         <div class="section-title">ITENS</div>
         
         {{range $index, $item := .NFe.NFe.InfNFe.Det}}
@@ -319,12 +478,12 @@ const danfeTemplate = `
                 <span class="item-code">{{printf "%02d" (add $index 1)}} - {{$item.Prod.CProd}}</span>
                 <span class="item-values">{{formatQuantity $item.Prod.QCom}}{{$item.Prod.UCom}} x {{formatCurrency $item.Prod.VUnCom}} = {{formatCurrency $item.Prod.VProd}}</span>
             </div>
-            <div class="item-desc">{{$item.Prod.XProd}}</div>
+            <div class="item-desc">{{$.ItemDescription $index $item}}</div>
         </div>
         {{end}}
-        
+        {{end}}
 
-        
+        {{block "totals" .}}
         <div class="section-title">TOTAIS</div>
         <div class="totals">
             <div class="total-line">
@@ -355,8 +514,10 @@ const danfeTemplate = `
                 <span>{{formatCurrency .NFe.NFe.InfNFe.Total.ICMSTot.VNF}}</span>
             </div>
         </div>
+        {{end}}
 
         
+        {{block "payment" .}}
         <div class="section-title">PAGAMENTO</div>
         <div class="payment">
             {{range .NFe.NFe.InfNFe.Pag.DetPag}}
@@ -372,28 +533,34 @@ const danfeTemplate = `
             </div>
             {{end}}
         </div>
+        {{end}}
 
         
+        {{block "consumer" .}}
         <div class="section-title">CONSUMIDOR</div>
         <div class="consumer">
             {{if .NFe.NFe.InfNFe.Dest}}
-                {{.NFe.NFe.InfNFe.Dest.XNome}}
+                {{.ConsumerName}}
             {{else}}
                 CONSUMIDOR NÃO IDENTIFICADO
             {{end}}
         </div>
+        {{end}}
 
         
+        {{block "footer" .}}
         <div class="nfc-info">
             NFC-e Nº {{.NFe.NFe.InfNFe.Ide.NNF}} Série {{.NFe.NFe.InfNFe.Ide.Serie}}<br>
-            Emissão: {{formatDate .NFe.NFe.InfNFe.Ide.DHEmi}}
+            Emissão: {{.FormatDate .NFe.NFe.InfNFe.Ide.DHEmi}}
         </div>
 
         
         <div class="section-title">DADOS DA NFC-e</div>
         <div class="footer">
+            {{if .NFe.ProtNFe.InfProt.NProt}}
             Protocolo: {{.NFe.ProtNFe.InfProt.NProt}}<br>
-            Autorização: {{formatDate .NFe.ProtNFe.InfProt.DhRecbto}}<br>
+            Autorização: {{.FormatDate .NFe.ProtNFe.InfProt.DhRecbto}}<br>
+            {{end}}
             <div class="key">{{formatKey .NFe.GetChaveAcesso}}</div>
         
 
@@ -411,12 +578,12 @@ const danfeTemplate = `
         {{if .NFe.GetQRCode}}
             
             <div class="qr-code">
-                {{generateQRCode .NFe.GetQRCode}}
+                {{.QRCode .NFe.GetQRCode}}
                 <div class="qr-text">Consulta via Leitor QR Code</div>
             </div>
             
         {{end}}
+        {{end}}
     </div>
-</body>
-</html>
+{{end}}
 `
@@ -0,0 +1,306 @@
+package renderer
+
+//go:generate go run ../schema/generate.go -o ../schema/nfce.schema.json
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/marcelo-cunha/nfce-render/xmlparser"
+)
+
+// JSONSchemaVersion é a versão do formato JSON gerado por JSONRenderer
+const JSONSchemaVersion = "1.0"
+
+// JSONRenderer é responsável pela serialização da NF-e em JSON
+type JSONRenderer struct {
+	config
+}
+
+// jsonDocument é a estrutura raiz do JSON. Os tipos do xmlparser são
+// convertidos com os nomes das tags xml em snake_case.
+type jsonDocument struct {
+	SchemaVersion string                   `json:"schema_version"`
+	ChaveAcesso   jsonChave                `json:"chave_acesso"`
+	Situacao      jsonSituacao             `json:"situacao"`
+	URLConsulta   string                   `json:"url_consulta,omitempty"`
+	QRCode        string                   `json:"qr_code,omitempty"`
+	Cancelamento  *xmlparser.ProcEventoNFe `json:"cancelamento,omitempty"`
+	NFeProc       *xmlparser.NFeProc       `json:"nfe_proc"`
+}
+
+// jsonChave contém a chave de acesso e suas partes
+type jsonChave struct {
+	Valor         string `json:"valor"`
+	Formatada     string `json:"formatada"`
+	CUF           string `json:"c_uf"`
+	UF            string `json:"uf"`
+	AAMM          string `json:"aamm"`
+	CNPJ          string `json:"cnpj"`
+	CNPJFormatado string `json:"cnpj_formatado"`
+	Modelo        string `json:"modelo"`
+	Serie         string `json:"serie"`
+	Numero        string `json:"numero"`
+	TpEmis        string `json:"tp_emis"`
+	CNF           string `json:"c_nf"`
+	DV            string `json:"dv"`
+}
+
+// jsonSituacao resume a situação do documento
+type jsonSituacao struct {
+	Status              string `json:"status"`
+	Cancelada           bool   `json:"cancelada"`
+	Homologacao         bool   `json:"homologacao"`
+	Contingencia        bool   `json:"contingencia"`
+	PendenteAutorizacao bool   `json:"pendente_autorizacao"`
+}
+
+// jsonHelper acrescenta um campo formatado ao lado do valor original
+type jsonHelper struct {
+	suffix      string
+	description string
+	format      func(string) string
+}
+
+// jsonHelpers relaciona os elementos do XML que recebem campos formatados
+var jsonHelpers = map[string]jsonHelper{
+	"CNPJ": {"_formatado", "CNPJ com máscara", xmlparser.FormatCNPJ},
+	"CPF":  {"_formatado", "CPF com máscara", xmlparser.FormatCPF},
+	"CEP":  {"_formatado", "CEP com máscara", xmlparser.FormatCEP},
+	"tPag": {"_descricao", "Descrição do meio de pagamento", xmlparser.GetPaymentMethodDescription},
+}
+
+// NewJSONRenderer cria uma nova instância do renderizador JSON
+func NewJSONRenderer(nfe *xmlparser.NFeProc, opts ...Option) *JSONRenderer {
+	return &JSONRenderer{
+		config: newConfig(nfe, opts),
+	}
+}
+
+// RenderToWriter serializa a NF-e em JSON para um io.Writer
+func (r *JSONRenderer) RenderToWriter(writer io.Writer) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(r.toJSON(reflect.ValueOf(r.document()))); err != nil {
+		return fmt.Errorf("erro ao gerar JSON: %w", err)
+	}
+
+	_, err := writer.Write(buf.Bytes())
+	return err
+}
+
+// document monta a estrutura raiz do JSON
+func (r *JSONRenderer) document() jsonDocument {
+	doc := jsonDocument{
+		SchemaVersion: JSONSchemaVersion,
+		Situacao: jsonSituacao{
+			Status:              r.nfe.GetStatus().String(),
+			Cancelada:           r.cancelamento != nil,
+			Homologacao:         r.isHomologacao(),
+			Contingencia:        r.nfe.IsContingenciaOffline(),
+			PendenteAutorizacao: r.pendente,
+		},
+		QRCode:       r.nfe.GetQRCode(),
+		Cancelamento: r.cancelamento,
+		NFeProc:      r.nfe,
+	}
+
+	chave := r.nfe.GetChaveAcesso()
+	doc.ChaveAcesso = jsonChave{Valor: chave, Formatada: xmlparser.FormatChaveAcesso(chave)}
+	if parts, err := xmlparser.ParseChaveAcesso(chave); err == nil {
+		doc.ChaveAcesso.CUF = parts.CUF
+		doc.ChaveAcesso.UF = xmlparser.UFFromCode(parts.CUF)
+		doc.ChaveAcesso.AAMM = parts.AAMM
+		doc.ChaveAcesso.CNPJ = parts.CNPJ
+		doc.ChaveAcesso.CNPJFormatado = xmlparser.FormatCNPJ(parts.CNPJ)
+		doc.ChaveAcesso.Modelo = parts.Modelo
+		doc.ChaveAcesso.Serie = parts.Serie
+		doc.ChaveAcesso.Numero = parts.Numero
+		doc.ChaveAcesso.TpEmis = parts.TpEmis
+		doc.ChaveAcesso.CNF = parts.CNF
+		doc.ChaveAcesso.DV = parts.DV
+	}
+	if supl := r.nfe.NFe.InfNFeSupl; supl != nil {
+		doc.URLConsulta = supl.UrlChave
+	}
+
+	return doc
+}
+
+// toJSON converte um valor em uma estrutura com ordem de campos estável
+func (r *JSONRenderer) toJSON(v reflect.Value) any {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return nil
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		var obj jsonObject
+		for _, f := range jsonFields(v.Type()) {
+			fv := v.Field(f.index)
+			if f.omitempty && fv.IsZero() {
+				continue
+			}
+			obj = append(obj, jsonField{f.name, r.toJSON(fv)})
+
+			if helper, ok := jsonHelpers[f.xmlName]; ok && fv.Kind() == reflect.String {
+				obj = append(obj, jsonField{f.name + helper.suffix, helper.format(fv.String())})
+			}
+			if isTimeType(fv.Type()) && !fv.IsZero() && (fv.Kind() != reflect.Pointer || !fv.Elem().IsZero()) {
+				t := reflect.Indirect(fv).Interface().(time.Time)
+				obj = append(obj, jsonField{f.name + "_formatada", r.formatDate(t)})
+			}
+		}
+		return obj
+	case reflect.Slice:
+		items := make([]any, v.Len())
+		for i := range items {
+			items[i] = r.toJSON(v.Index(i))
+		}
+		return items
+	default:
+		return v.Interface()
+	}
+}
+
+// jsonObject é um objeto JSON que preserva a ordem dos campos
+type jsonObject []jsonField
+
+// jsonField é um campo de jsonObject
+type jsonField struct {
+	key   string
+	value any
+}
+
+// MarshalJSON implementa json.Marshaler
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := marshalJSON(f.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := marshalJSON(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalJSON serializa o valor sem escapar caracteres HTML como & e <
+func marshalJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// jsonFieldInfo descreve um campo serializado em JSON
+type jsonFieldInfo struct {
+	index     int
+	name      string
+	xmlName   string
+	omitempty bool
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	xmlNameType = reflect.TypeOf(xml.Name{})
+)
+
+// isTimeType verifica se o tipo é time.Time ou *time.Time
+func isTimeType(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t == timeType
+}
+
+// jsonFields lista os campos serializados de uma struct. Campos com tag
+// json usam o nome da tag; os demais usam a tag xml em snake_case.
+func jsonFields(t reflect.Type) []jsonFieldInfo {
+	var fields []jsonFieldInfo
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || f.Type == xmlNameType {
+			continue
+		}
+
+		info := jsonFieldInfo{index: i}
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			info.name = parts[0]
+			info.omitempty = len(parts) > 1 && parts[1] == "omitempty"
+		} else {
+			parts := strings.Split(f.Tag.Get("xml"), ",")
+			if parts[0] == "-" || (parts[0] == "xmlns" && len(parts) > 1 && parts[1] == "attr") {
+				continue
+			}
+			info.xmlName = parts[0]
+			if info.xmlName == "" {
+				info.xmlName = f.Name
+			}
+			info.name = snakeCase(info.xmlName)
+			for _, opt := range parts[1:] {
+				if opt == "omitempty" {
+					info.omitempty = true
+				}
+			}
+		}
+		fields = append(fields, info)
+	}
+	return fields
+}
+
+// snakeCase converte os nomes do leiaute da NF-e (vUnCom, ICMSTot, infNFeSupl)
+// para snake_case (v_un_com, icms_tot, inf_nfe_supl)
+func snakeCase(name string) string {
+	runes := []rune(strings.ReplaceAll(name, "NFe", "Nfe"))
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
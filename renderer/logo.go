@@ -0,0 +1,262 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/fs"
+	"math"
+)
+
+// Formatos de imagem aceitos para o logotipo
+const (
+	LogoPNG  = "image/png"
+	LogoJPEG = "image/jpeg"
+	LogoSVG  = "image/svg+xml"
+)
+
+// LogoOptions define como o logotipo é ajustado ao cabeçalho do DANFE
+type LogoOptions struct {
+	// MaxWidthMM é a largura máxima em milímetros. Sem valor, o logotipo
+	// ocupa no máximo a área útil do papel usado em cada renderização.
+	MaxWidthMM float64
+	// MaxHeightMM é a altura máxima em milímetros (padrão: 20)
+	MaxHeightMM float64
+	// DPI é a resolução usada para converter milímetros em pixels (padrão: 203,
+	// resolução das impressoras térmicas)
+	DPI int
+	// Monochrome converte a imagem para 1 bit (preto e branco) com pontilhado,
+	// como as impressoras térmicas imprimem
+	Monochrome bool
+}
+
+// Logo é o logotipo do estabelecimento, pronto para ser embutido no HTML
+type Logo struct {
+	mimeType string
+	data     []byte
+	widthMM  float64 // largura máxima; zero usa a área útil do papel
+	heightMM float64
+	filter   bool // SVG monocromático: aplicado por CSS
+}
+
+// LoadLogo prepara um logotipo PNG, JPEG ou SVG. Imagens PNG e JPEG são
+// reduzidas para caber em MaxWidthMM x MaxHeightMM e reescritas como PNG.
+// Imagens SVG são mantidas vetoriais e limitadas por CSS.
+func LoadLogo(data []byte, opts LogoOptions) (*Logo, error) {
+	if opts.MaxWidthMM < 0 {
+		opts.MaxWidthMM = 0
+	}
+	if opts.MaxHeightMM <= 0 {
+		opts.MaxHeightMM = 20
+	}
+	if opts.DPI <= 0 {
+		opts.DPI = 203
+	}
+
+	switch mimeType := detectImageType(data); mimeType {
+	case LogoSVG:
+		return &Logo{
+			mimeType: LogoSVG,
+			data:     data,
+			widthMM:  opts.MaxWidthMM,
+			heightMM: opts.MaxHeightMM,
+			filter:   opts.Monochrome,
+		}, nil
+	case LogoPNG, LogoJPEG:
+		var img image.Image
+		var err error
+		if mimeType == LogoPNG {
+			img, err = png.Decode(bytes.NewReader(data))
+		} else {
+			img, err = jpeg.Decode(bytes.NewReader(data))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro ao decodificar logotipo: %w", err)
+		}
+		return rasterLogo(img, opts)
+	default:
+		return nil, fmt.Errorf("formato de logotipo não suportado: use PNG, JPEG ou SVG")
+	}
+}
+
+// LoadLogoFS lê o logotipo do arquivo name em fsys e o prepara com LoadLogo
+func LoadLogoFS(fsys fs.FS, name string, opts LogoOptions) (*Logo, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler logotipo: %w", err)
+	}
+	return LoadLogo(data, opts)
+}
+
+// DataURI retorna o logotipo como data URI, para uso no atributo src
+func (l *Logo) DataURI() template.URL {
+	return template.URL("data:" + l.mimeType + ";base64," + base64.StdEncoding.EncodeToString(l.data))
+}
+
+// MimeType retorna o formato do logotipo embutido
+func (l *Logo) MimeType() string {
+	return l.mimeType
+}
+
+// Bytes retorna a imagem do logotipo, já reduzida
+func (l *Logo) Bytes() []byte {
+	return l.data
+}
+
+// style retorna as dimensões máximas do logotipo em CSS, limitadas à
+// largura disponível no papel
+func (l *Logo) style(availableMM float64) template.CSS {
+	width := availableMM
+	if l.widthMM > 0 {
+		width = min(width, l.widthMM)
+	}
+	style := fmt.Sprintf("max-width: %.1fmm; max-height: %.1fmm;", width, l.heightMM)
+	if l.filter {
+		style += " filter: grayscale(1) contrast(100);"
+	}
+	return template.CSS(style)
+}
+
+// detectImageType identifica o formato da imagem pelo conteúdo
+func detectImageType(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return LogoPNG
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return LogoJPEG
+	}
+
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	if bytes.Contains(head, []byte("<svg")) {
+		return LogoSVG
+	}
+	return ""
+}
+
+// rasterLogo reduz a imagem e a codifica como PNG
+func rasterLogo(img image.Image, opts LogoOptions) (*Logo, error) {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return nil, fmt.Errorf("logotipo sem pixels")
+	}
+
+	// Sem largura máxima, a imagem é reduzida para a maior área útil entre
+	// os papéis; a largura de cada papel é aplicada na renderização
+	widthMM := opts.MaxWidthMM
+	if widthMM == 0 {
+		widthMM = PaperA4.columnWidthMM()
+	}
+	maxW := mmToPixels(widthMM, opts.DPI)
+	maxH := mmToPixels(opts.MaxHeightMM, opts.DPI)
+	scale := math.Min(1, math.Min(float64(maxW)/float64(bounds.Dx()), float64(maxH)/float64(bounds.Dy())))
+	w := max(1, int(math.Round(float64(bounds.Dx())*scale)))
+	h := max(1, int(math.Round(float64(bounds.Dy())*scale)))
+
+	var out image.Image = resize(img, w, h)
+	if opts.Monochrome {
+		out = dither(out)
+	}
+
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, out); err != nil {
+		return nil, fmt.Errorf("erro ao codificar logotipo: %w", err)
+	}
+
+	return &Logo{
+		mimeType: LogoPNG,
+		data:     buf.Bytes(),
+		widthMM:  pixelsToMM(w, opts.DPI),
+		heightMM: pixelsToMM(h, opts.DPI),
+	}, nil
+}
+
+// resize reduz a imagem para w x h pixels pela média das áreas (box filter)
+func resize(img image.Image, w, h int) *image.NRGBA {
+	bounds := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, w, h))
+	sx := float64(bounds.Dx()) / float64(w)
+	sy := float64(bounds.Dy()) / float64(h)
+
+	for y := 0; y < h; y++ {
+		y0 := bounds.Min.Y + int(float64(y)*sy)
+		y1 := max(y0+1, bounds.Min.Y+int(float64(y+1)*sy))
+		for x := 0; x < w; x++ {
+			x0 := bounds.Min.X + int(float64(x)*sx)
+			x1 := max(x0+1, bounds.Min.X+int(float64(x+1)*sx))
+
+			var r, g, b, a, n uint64
+			for py := y0; py < y1; py++ {
+				for px := x0; px < x1; px++ {
+					pr, pg, pb, pa := img.At(px, py).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			// Média com alfa pré-multiplicado, convertida para NRGBA
+			c := color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)}
+			out.Set(x, y, c)
+		}
+	}
+	return out
+}
+
+// dither converte a imagem para 1 bit com o pontilhado de Floyd-Steinberg.
+// Áreas transparentes são tratadas como papel (branco).
+func dither(img image.Image) *image.Paletted {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	lum := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, b, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			// Compõe sobre fundo branco
+			white := float64(0xFFFF - a)
+			l := 0.299*(float64(r)+white) + 0.587*(float64(g)+white) + 0.114*(float64(b)+white)
+			lum[y*w+x] = l / 0xFFFF * 255
+		}
+	}
+
+	out := image.NewPaletted(image.Rect(0, 0, w, h), color.Palette{color.Black, color.White})
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			old := lum[y*w+x]
+			var v float64
+			if old >= 128 {
+				v = 255
+				out.SetColorIndex(x, y, 1)
+			}
+			diff := old - v
+			spread := func(dx, dy int, factor float64) {
+				nx, ny := x+dx, y+dy
+				if nx >= 0 && nx < w && ny < h {
+					lum[ny*w+nx] += diff * factor
+				}
+			}
+			spread(1, 0, 7.0/16)
+			spread(-1, 1, 3.0/16)
+			spread(0, 1, 5.0/16)
+			spread(1, 1, 1.0/16)
+		}
+	}
+	return out
+}
+
+// mmToPixels converte milímetros em pixels na resolução informada
+func mmToPixels(mm float64, dpi int) int {
+	return max(1, int(mm/25.4*float64(dpi)))
+}
+
+// pixelsToMM converte pixels em milímetros na resolução informada
+func pixelsToMM(px, dpi int) float64 {
+	return float64(px) / float64(dpi) * 25.4
+}
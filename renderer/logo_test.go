@@ -0,0 +1,43 @@
+package renderer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLogoStyleFollowsPaper(t *testing.T) {
+	svg := []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="400" height="100"></svg>`)
+	logo, err := LoadLogo(svg, LogoOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		paper PaperProfile
+		want  string
+	}{
+		{Paper58mm, "max-width: 45.0mm;"},
+		{Paper80mm, "max-width: 76.0mm;"},
+	} {
+		var buf bytes.Buffer
+		if err := NewHTMLRenderer(sampleNFe(), WithLogo(logo), WithPaper(tc.paper)).RenderToWriter(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), tc.want) {
+			t.Errorf("papel %s: estilo %q não encontrado", tc.paper.Name, tc.want)
+		}
+	}
+
+	limited, err := LoadLogo(svg, LogoOptions{MaxWidthMM: 40})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := NewHTMLRenderer(sampleNFe(), WithLogo(limited), WithPaper(Paper58mm)).RenderToWriter(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "max-width: 40.0mm;") {
+		t.Error("MaxWidthMM não aplicado")
+	}
+}
@@ -0,0 +1,127 @@
+package renderer
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/marcelo-cunha/nfce-render/xmlparser"
+)
+
+// MessageRenderer gera o DANFE NFC-e em mensagem eletrônica: a versão curta,
+// sem itens, enviada ao consumidor por e-mail, SMS ou aplicativo
+type MessageRenderer struct {
+	config
+}
+
+// NewMessageRenderer cria uma nova instância do renderizador de mensagem
+func NewMessageRenderer(nfe *xmlparser.NFeProc, opts ...Option) *MessageRenderer {
+	return &MessageRenderer{
+		config: newConfig(nfe, opts),
+	}
+}
+
+// messageData contém os campos da mensagem eletrônica
+type messageData struct {
+	Avisos     []string
+	Emitente   string
+	CNPJ       string
+	Numero     string
+	Serie      string
+	Emissao    string
+	Total      string
+	Chave      string
+	ConsultURL string
+	QRCode     string
+}
+
+// RenderText escreve a mensagem em texto simples
+func (r *MessageRenderer) RenderText(writer io.Writer) error {
+	d := r.messageData()
+
+	var b strings.Builder
+	for _, aviso := range d.Avisos {
+		b.WriteString(aviso + "\n")
+	}
+	fmt.Fprintf(&b, "%s\nCNPJ: %s\n", d.Emitente, d.CNPJ)
+	fmt.Fprintf(&b, "NFC-e nº %s Série %s\nEmissão: %s\nValor total: %s\n", d.Numero, d.Serie, d.Emissao, d.Total)
+	fmt.Fprintf(&b, "Chave de acesso: %s\n", d.Chave)
+	if d.ConsultURL != "" {
+		fmt.Fprintf(&b, "Consulte em: %s\n", d.ConsultURL)
+	}
+	if d.QRCode != "" {
+		fmt.Fprintf(&b, "QR Code: %s\n", d.QRCode)
+	}
+
+	_, err := io.WriteString(writer, b.String())
+	return err
+}
+
+// RenderHTML escreve a mensagem como um trecho de HTML, para ser incluído no
+// corpo de um e-mail ou de uma mensagem do aplicativo
+func (r *MessageRenderer) RenderHTML(writer io.Writer) error {
+	d := r.messageData()
+	if d.ConsultURL != "" && !strings.Contains(d.ConsultURL, "://") {
+		d.ConsultURL = "http://" + d.ConsultURL
+	}
+	if err := messageTemplate.Execute(writer, d); err != nil {
+		return fmt.Errorf("erro ao renderizar mensagem: %w", err)
+	}
+	return nil
+}
+
+// messageData monta os campos exigidos pelo manual do DANFE NFC-e em
+// mensagem eletrônica
+func (c *config) messageData() messageData {
+	inf := &c.nfe.NFe.InfNFe
+	d := messageData{
+		Emitente: inf.Emit.XNome,
+		CNPJ:     xmlparser.FormatCNPJ(inf.Emit.CNPJ),
+		Numero:   inf.Ide.NNF,
+		Serie:    inf.Ide.Serie,
+		Emissao:  c.formatDate(inf.Ide.DHEmi),
+		Total:    xmlparser.FormatCurrency(inf.Total.ICMSTot.VNF),
+		Chave:    xmlparser.FormatChaveAcesso(c.nfe.GetChaveAcesso()),
+		QRCode:   c.nfe.GetQRCode(),
+	}
+	if supl := c.nfe.NFe.InfNFeSupl; supl != nil {
+		d.ConsultURL = supl.UrlChave
+	}
+
+	if c.isHomologacao() {
+		d.Avisos = append(d.Avisos, xmlparser.MensagemHomologacao)
+	}
+	if c.nfe.IsContingenciaOffline() {
+		aviso := "EMITIDA EM CONTINGÊNCIA"
+		if c.pendente {
+			aviso += " - Pendente de autorização"
+		}
+		d.Avisos = append(d.Avisos, aviso)
+	}
+	if c.statusBanner != "" {
+		d.Avisos = append(d.Avisos, c.statusBanner)
+	}
+	if c.cancelamento != nil {
+		d.Avisos = append(d.Avisos, "NFC-e CANCELADA")
+	}
+	return d
+}
+
+// messageTemplate é o trecho HTML da mensagem eletrônica, com estilos inline
+// para funcionar nos clientes de e-mail
+var messageTemplate = template.Must(template.New("mensagem").Parse(`<div class="danfe-mensagem" style="font-family: Arial, sans-serif; font-size: 14px;">
+{{- range .Avisos}}
+<p style="margin: 0 0 8px; font-weight: bold; color: #c00;">{{.}}</p>
+{{- end}}
+<p style="margin: 0 0 8px;"><strong>{{.Emitente}}</strong><br>CNPJ: {{.CNPJ}}</p>
+<p style="margin: 0 0 8px;">NFC-e nº {{.Numero}} Série {{.Serie}}<br>Emissão: {{.Emissao}}<br>Valor total: <strong>{{.Total}}</strong></p>
+<p style="margin: 0 0 8px;">Chave de acesso:<br><span style="font-family: monospace;">{{.Chave}}</span></p>
+{{- if .ConsultURL}}
+<p style="margin: 0 0 8px;">Consulte pela chave de acesso em <a href="{{.ConsultURL}}">{{.ConsultURL}}</a></p>
+{{- end}}
+{{- if .QRCode}}
+<p style="margin: 0;"><a href="{{.QRCode}}">Consultar NFC-e pelo QR Code</a></p>
+{{- end}}
+</div>
+`))
@@ -0,0 +1,205 @@
+package renderer
+
+import (
+	"time"
+
+	"github.com/marcelo-cunha/nfce-render/converter"
+	"github.com/marcelo-cunha/nfce-render/xmlparser"
+)
+
+// Rótulos das vias impressas em contingência offline
+const (
+	ViaConsumidor      = "Via do consumidor"
+	ViaEstabelecimento = "Via do estabelecimento"
+)
+
+// config contém as opções comuns a todos os renderizadores
+type config struct {
+	nfe          *xmlparser.NFeProc
+	location     *time.Location
+	cancelamento *xmlparser.ProcEventoNFe
+	statusBanner string
+	homologacao  bool
+	pendente     bool
+	vias         []string
+	template     *Template
+	logo         *Logo
+	paper        PaperProfile
+	columns      int
+	printer      PrinterProfile
+	qrcode       QRCodeOptions
+	raster       RasterOptions
+	sourceXML    []byte
+	pdfa         converter.PDFA
+}
+
+// Option configura os renderizadores
+type Option func(*config)
+
+// newConfig aplica as opções sobre a configuração padrão
+func newConfig(nfe *xmlparser.NFeProc, opts []Option) config {
+	c := config{nfe: nfe, paper: Paper80mm}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.paper.Name == "" {
+		c.paper = Paper80mm
+	}
+	if c.qrcode.SizeMM > 0 {
+		c.paper.QRCodeMM = c.qrcode.SizeMM
+	}
+	return c
+}
+
+// WithLocation define o fuso horário usado na exibição das datas.
+// Quando nil, cada data é exibida com o fuso que veio no XML.
+func WithLocation(loc *time.Location) Option {
+	return func(c *config) {
+		c.location = loc
+	}
+}
+
+// WithCancelamento marca o DANFE como cancelado, exibindo a marca d'água
+// "CANCELADA" e os dados do evento de cancelamento
+func WithCancelamento(ev *xmlparser.ProcEventoNFe) Option {
+	return func(c *config) {
+		c.cancelamento = ev
+	}
+}
+
+// WithStatusBanner exibe uma faixa de destaque com a situação da NF-e,
+// como "DENEGADA" ou "NÃO AUTORIZADA"
+func WithStatusBanner(text string) Option {
+	return func(c *config) {
+		c.statusBanner = text
+	}
+}
+
+// WithHomologacao força as marcações de ambiente de homologação mesmo
+// quando o XML foi emitido em produção
+func WithHomologacao(force bool) Option {
+	return func(c *config) {
+		c.homologacao = force
+	}
+}
+
+// WithPendenteAutorizacao exibe a mensagem "Pendente de autorização" nas
+// NFC-e emitidas em contingência offline que ainda não foram autorizadas
+func WithPendenteAutorizacao(pendente bool) Option {
+	return func(c *config) {
+		c.pendente = pendente
+	}
+}
+
+// WithVias gera uma cópia do DANFE para cada rótulo informado, separadas
+// por uma marca de corte. Sem rótulos é gerada uma única via.
+func WithVias(vias ...string) Option {
+	return func(c *config) {
+		c.vias = vias
+	}
+}
+
+// WithTemplate usa um template obtido com ParseTemplate ou ParseTemplateFS
+// no lugar do template padrão
+func WithTemplate(t *Template) Option {
+	return func(c *config) {
+		c.template = t
+	}
+}
+
+// WithLogo exibe o logotipo do estabelecimento no cabeçalho do DANFE
+func WithLogo(logo *Logo) Option {
+	return func(c *config) {
+		c.logo = logo
+	}
+}
+
+// WithPaper define o perfil de papel do DANFE (padrão: Paper80mm)
+func WithPaper(p PaperProfile) Option {
+	return func(c *config) {
+		c.paper = p
+	}
+}
+
+// WithColumns define o número de colunas da saída em texto, normalmente
+// Columns32, Columns42 ou Columns48. Zero usa a largura do papel.
+func WithColumns(n int) Option {
+	return func(c *config) {
+		c.columns = n
+	}
+}
+
+// WithPrinter define o perfil da impressora da saída ESC/POS (padrão: PrinterEpson)
+func WithPrinter(p PrinterProfile) Option {
+	return func(c *config) {
+		c.printer = p
+	}
+}
+
+// WithQRCode define o nível de correção, a margem, o tamanho e o formato do
+// QR Code
+func WithQRCode(opts QRCodeOptions) Option {
+	return func(c *config) {
+		c.qrcode = opts
+	}
+}
+
+// WithRaster define a resolução e a largura das saídas em imagem
+func WithRaster(opts RasterOptions) Option {
+	return func(c *config) {
+		c.raster = opts
+	}
+}
+
+// WithSourceXML informa o XML original da NF-e, cujo SHA-256 entra nos
+// metadados do PDF e que é embutido no PDF/A-3
+func WithSourceXML(data []byte) Option {
+	return func(c *config) {
+		c.sourceXML = data
+	}
+}
+
+// WithPDFA define a conformidade PDF/A do PDF
+func WithPDFA(pdfa converter.PDFA) Option {
+	return func(c *config) {
+		c.pdfa = pdfa
+	}
+}
+
+// isHomologacao verifica se as marcações de homologação devem ser aplicadas
+func (c *config) isHomologacao() bool {
+	return c.homologacao || c.nfe.IsHomologacao()
+}
+
+// itemDescription retorna a descrição exibida para o item. Em homologação o
+// primeiro item recebe o texto padrão exigido pelo manual do DANFE NFC-e.
+func (c *config) itemDescription(index int, det xmlparser.Det) string {
+	if index == 0 && c.isHomologacao() {
+		return xmlparser.XProdHomologacao
+	}
+	return det.Prod.XProd
+}
+
+// consumerName retorna o nome exibido para o consumidor identificado
+func (c *config) consumerName() string {
+	if c.isHomologacao() {
+		return xmlparser.XNomeHomologacao
+	}
+	if c.nfe.NFe.InfNFe.Dest == nil {
+		return ""
+	}
+	return c.nfe.NFe.InfNFe.Dest.XNome
+}
+
+// localTime converte a data para o fuso configurado no renderizador
+func (c *config) localTime(t time.Time) time.Time {
+	if c.location == nil {
+		return t
+	}
+	return t.In(c.location)
+}
+
+// formatDate formata data e hora no fuso configurado
+func (c *config) formatDate(t time.Time) string {
+	return c.localTime(t).Format("02/01/2006 15:04:05")
+}
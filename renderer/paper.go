@@ -0,0 +1,114 @@
+package renderer
+
+import (
+	"fmt"
+	"html/template"
+)
+
+// PaperProfile define as dimensões do papel e do DANFE impresso nele
+type PaperProfile struct {
+	Name string
+
+	// PageWidthMM e PageHeightMM são as dimensões da página. Altura zero
+	// indica rolo contínuo (altura definida pelo conteúdo).
+	PageWidthMM  float64
+	PageHeightMM float64
+	// PageMarginMM é a margem da página
+	PageMarginMM float64
+
+	// ContentWidthMM é a largura do DANFE dentro da página
+	ContentWidthMM float64
+	// PaddingMM é o espaçamento interno do DANFE
+	PaddingMM float64
+	// Columns é o número de colunas do DANFE (2 apenas em A4)
+	Columns int
+	// ColumnGapMM é o espaço entre as colunas, no HTML e no PDF
+	ColumnGapMM float64
+
+	// FontScale multiplica os tamanhos de fonte do template padrão
+	FontScale float64
+	// QRCodeMM é o lado do QR Code impresso
+	QRCodeMM float64
+}
+
+// Perfis de papel suportados
+var (
+	// Paper58mm é a bobina térmica de 58mm. As impressoras de 58mm imprimem
+	// apenas os 48mm centrais, então o DANFE ocupa essa largura.
+	Paper58mm = PaperProfile{
+		Name:           "58mm",
+		PageWidthMM:    58,
+		ContentWidthMM: 48,
+		PaddingMM:      1.5,
+		Columns:        1,
+		FontScale:      0.8,
+		QRCodeMM:       22,
+	}
+
+	// Paper80mm é a bobina térmica de 80mm (padrão)
+	Paper80mm = PaperProfile{
+		Name:           "80mm",
+		PageWidthMM:    80,
+		ContentWidthMM: 80,
+		PaddingMM:      2,
+		Columns:        1,
+		FontScale:      1,
+		QRCodeMM:       25,
+	}
+
+	// PaperA4 é a folha A4 em retrato com o DANFE centralizado
+	PaperA4 = PaperProfile{
+		Name:           "a4",
+		PageWidthMM:    210,
+		PageHeightMM:   297,
+		PageMarginMM:   10,
+		ContentWidthMM: 100,
+		PaddingMM:      4,
+		Columns:        1,
+		FontScale:      1.2,
+		QRCodeMM:       30,
+	}
+
+	// PaperA4TwoColumns é a folha A4 em retrato com o DANFE em duas colunas,
+	// para notas com muitos itens
+	PaperA4TwoColumns = PaperProfile{
+		Name:           "a4-2col",
+		PageWidthMM:    210,
+		PageHeightMM:   297,
+		PageMarginMM:   10,
+		ContentWidthMM: 190,
+		PaddingMM:      4,
+		Columns:        2,
+		ColumnGapMM:    6,
+		FontScale:      1.1,
+		QRCodeMM:       30,
+	}
+)
+
+// IsRoll verifica se o papel é um rolo contínuo (bobina térmica)
+func (p PaperProfile) IsRoll() bool {
+	return p.PageHeightMM == 0
+}
+
+// columnWidthMM retorna a largura útil de cada coluna do DANFE, descontado
+// o espaçamento interno
+func (p PaperProfile) columnWidthMM() float64 {
+	n := max(1, p.Columns)
+	return (p.ContentWidthMM-float64(n-1)*p.ColumnGapMM)/float64(n) - 2*p.PaddingMM
+}
+
+// PageSize retorna o valor da regra CSS @page size
+func (p PaperProfile) PageSize() template.CSS {
+	if p.IsRoll() {
+		return template.CSS(fmt.Sprintf("%gmm auto", p.PageWidthMM))
+	}
+	return template.CSS(fmt.Sprintf("%gmm %gmm", p.PageWidthMM, p.PageHeightMM))
+}
+
+// CSSVariables retorna as variáveis CSS usadas pelo template padrão
+func (p PaperProfile) CSSVariables() template.CSS {
+	return template.CSS(fmt.Sprintf(
+		"--page-margin: %gmm; --body-width: %gmm; --content-width: %gmm; --padding: %gmm; --columns: %d; --column-gap: %gmm; --font-scale: %g; --qr-size: %gmm;",
+		p.PageMarginMM, p.PageWidthMM-2*p.PageMarginMM, p.ContentWidthMM, p.PaddingMM, p.Columns, p.ColumnGapMM, p.FontScale, p.QRCodeMM,
+	))
+}
@@ -0,0 +1,42 @@
+package renderer
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestPaper58mmPrintableArea(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewHTMLRenderer(sampleNFe(), WithPaper(Paper58mm)).RenderToWriter(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "--content-width: 48mm;") {
+		t.Error("DANFE em 58mm fora dos 48mm de área útil")
+	}
+
+	c := newConfig(sampleNFe(), []Option{WithPaper(Paper58mm)})
+	l := c.pdfLayout(c.textColumns())
+	if left, right := l.colX[0], Paper58mm.PageWidthMM-l.colX[0]-l.innerW; left < 5 || math.Abs(left-right) > 1e-9 {
+		t.Errorf("texto do PDF entre %gmm e %gmm da borda", left, right)
+	}
+}
+
+func TestColumnGapSharedByHTMLAndPDF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewHTMLRenderer(sampleNFe(), WithPaper(PaperA4TwoColumns)).RenderToWriter(&buf); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+	if !strings.Contains(html, "--column-gap: 6mm;") || !strings.Contains(html, "column-gap: var(--column-gap);") {
+		t.Error("espaço entre colunas do HTML não vem do papel")
+	}
+
+	c := newConfig(sampleNFe(), []Option{WithPaper(PaperA4TwoColumns)})
+	l := c.pdfLayout(c.textColumns())
+	gap := l.colX[1] - l.colX[0] - l.innerW - 2*PaperA4TwoColumns.PaddingMM
+	if math.Abs(gap-PaperA4TwoColumns.ColumnGapMM) > 1e-9 {
+		t.Errorf("espaço entre colunas do PDF %gmm, esperado %gmm", gap, PaperA4TwoColumns.ColumnGapMM)
+	}
+}
@@ -0,0 +1,346 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/marcelo-cunha/nfce-render/converter"
+	"github.com/marcelo-cunha/nfce-render/xmlparser"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+)
+
+// PDFRenderer escreve o DANFE diretamente em PDF, sem HTML nem conversor
+// externo. O texto usa a fonte Go Mono embutida, reduzida aos caracteres
+// usados, o QR Code é vetorial e a saída é determinística: a mesma NF-e gera
+// sempre os mesmos bytes.
+type PDFRenderer struct {
+	config
+}
+
+// NewPDFRenderer cria uma nova instância do renderizador PDF
+func NewPDFRenderer(nfe *xmlparser.NFeProc, opts ...Option) *PDFRenderer {
+	return &PDFRenderer{
+		config: newConfig(nfe, opts),
+	}
+}
+
+// Métricas da fonte Go Mono em unidades de 1/1000 do corpo
+const (
+	monoAdvance   = 0.6 // avanço de todos os caracteres, em em
+	monoAscent    = 945
+	monoDescent   = -211
+	monoCapHeight = 723
+)
+
+// pdfBlock é uma linha do cupom já posicionável: altura e desenho. Blocos
+// com newColumn apenas iniciam uma nova coluna ou página.
+type pdfBlock struct {
+	height    float64 // em mm
+	draw      func(b *strings.Builder, x, top float64)
+	newColumn bool
+}
+
+// pdfLayout contém as medidas de uma página do DANFE, em milímetros
+type pdfLayout struct {
+	pageW, pageH float64
+	colX         []float64 // início da área de texto de cada coluna
+	innerW       float64   // largura da área de texto
+	top, bottom  float64   // limites verticais do conteúdo (distância do topo)
+	charW        float64
+	fontPt       float64
+	lineH        float64
+}
+
+// RenderToWriter escreve o DANFE em PDF no io.Writer
+func (r *PDFRenderer) RenderToWriter(writer io.Writer) error {
+	doc := r.pdfDocument()
+	if err := checkPDFA(doc); err != nil {
+		return err
+	}
+
+	columns := r.textColumns()
+	layout := r.pdfLayout(columns)
+
+	var logo *image.NRGBA
+	if r.logo != nil {
+		var err error
+		if logo, err = pdfLogo(r.logo); err != nil {
+			return err
+		}
+	}
+
+	var charsets [2]pdfCharset
+	blocks, err := r.pdfBlocks(layout, columns, logo, &charsets)
+	if err != nil {
+		return err
+	}
+
+	// Distribui os blocos em colunas e páginas. Em bobina a página tem a
+	// altura do conteúdo.
+	if layout.pageH == 0 {
+		height := layout.top
+		for _, block := range blocks {
+			height += block.height
+		}
+		layout.pageH = height + layout.top
+		layout.bottom = layout.pageH - layout.top
+	}
+	var pages []string
+	var page strings.Builder
+	col, y := 0, layout.top
+	for _, block := range blocks {
+		if block.newColumn {
+			y = layout.bottom
+			continue
+		}
+		if y+block.height > layout.bottom && y > layout.top {
+			col++
+			y = layout.top
+			if col == len(layout.colX) {
+				pages = append(pages, page.String())
+				page.Reset()
+				col = 0
+			}
+		}
+		block.draw(&page, layout.colX[col], layout.pageH-y)
+		y += block.height
+	}
+	pages = append(pages, page.String())
+
+	return writePDF(writer, layout, pages, logo, doc, charsets)
+}
+
+// pdfLayout calcula as medidas da página a partir do papel e do número de colunas
+func (c *config) pdfLayout(columns int) pdfLayout {
+	p := c.paper
+	l := pdfLayout{pageW: p.PageWidthMM, pageH: p.PageHeightMM}
+
+	l.innerW = p.columnWidthMM()
+	colW := l.innerW + 2*p.PaddingMM
+	left := (p.PageWidthMM - p.ContentWidthMM) / 2
+	for i := 0; i < max(1, p.Columns); i++ {
+		l.colX = append(l.colX, left+float64(i)*(colW+p.ColumnGapMM)+p.PaddingMM)
+	}
+
+	l.top = p.PageMarginMM + p.PaddingMM
+	l.bottom = p.PageHeightMM - p.PageMarginMM - p.PaddingMM
+
+	l.charW = l.innerW / float64(columns)
+	l.fontPt = mmToPt(l.charW / monoAdvance)
+	l.lineH = l.charW / monoAdvance * 1.2
+	return l
+}
+
+// pdfBlocks converte as linhas do cupom em blocos desenháveis e registra em
+// charsets os caracteres usados com a fonte normal e a negrito
+func (c *config) pdfBlocks(l pdfLayout, columns int, logo *image.NRGBA, charsets *[2]pdfCharset) ([]pdfBlock, error) {
+	var blocks []pdfBlock
+	for _, line := range c.receipt(columns) {
+		line := line
+		switch line.kind {
+		case lineQRCode:
+			matrix, err := qrMatrix(line.text, c.qrcode)
+			if err != nil {
+				return nil, err
+			}
+			size := min(c.paper.QRCodeMM, l.innerW)
+			blocks = append(blocks, pdfBlock{height: size + l.lineH/2, draw: func(b *strings.Builder, x, top float64) {
+				drawQRCode(b, matrix, x+(l.innerW-size)/2, top, size)
+			}})
+		case lineLogo:
+			if logo == nil {
+				continue
+			}
+			w, h := min(c.logo.widthMM, l.innerW), c.logo.heightMM
+			if c.logo.widthMM > l.innerW {
+				h = c.logo.heightMM * l.innerW / c.logo.widthMM
+			}
+			blocks = append(blocks, pdfBlock{height: h + l.lineH/2, draw: func(b *strings.Builder, x, top float64) {
+				fmt.Fprintf(b, "q %.3f 0 0 %.3f %.3f %.3f cm /Logo Do Q\n",
+					mmToPt(w), mmToPt(h), mmToPt(x+(l.innerW-w)/2), mmToPt(top-h))
+			}})
+		case lineCut:
+			// Em papel com páginas, cada via começa em uma nova coluna
+			if l.pageH > 0 {
+				blocks = append(blocks, pdfBlock{newColumn: true})
+				continue
+			}
+			blocks = append(blocks, pdfBlock{height: l.lineH * 2, draw: func(b *strings.Builder, x, top float64) {
+				y := mmToPt(top - l.lineH)
+				fmt.Fprintf(b, "q [3 3] 0 d 0.5 w %.3f %.3f m %.3f %.3f l S Q\n", mmToPt(x), y, mmToPt(x+l.innerW), y)
+			}})
+		default:
+			height := l.lineH
+			if line.large {
+				height *= 2
+			}
+			text := alignText(line.text, line.align, columns)
+			trimmed := strings.TrimLeft(text, " ")
+			encoded := winAnsi(strings.TrimRight(trimmed, " "))
+			font, scale := "/F1", 1
+			if line.bold {
+				font = "/F2"
+				charsets[1].add(encoded)
+			} else {
+				charsets[0].add(encoded)
+			}
+			if line.large {
+				scale = 2
+			}
+			blocks = append(blocks, pdfBlock{height: height, draw: func(b *strings.Builder, x, top float64) {
+				if trimmed == "" {
+					return
+				}
+				x += float64(len([]rune(text))-len([]rune(trimmed))) * l.charW
+				baseline := top - height + (l.lineH-l.charW/monoAdvance)/2*float64(scale) - float64(monoDescent)/1000*l.charW/monoAdvance*float64(scale)
+				fmt.Fprintf(b, "BT %s %.3f Tf 1 0 0 %d %.3f %.3f Tm <%s> Tj ET\n",
+					font, l.fontPt, scale, mmToPt(x), mmToPt(baseline), hex.EncodeToString(encoded))
+			}})
+		}
+	}
+	return blocks, nil
+}
+
+// drawQRCode desenha o QR Code com retângulos, unindo os módulos vizinhos
+// de cada linha
+func drawQRCode(b *strings.Builder, matrix [][]bool, x, top, size float64) {
+	module := size / float64(len(matrix))
+	b.WriteString("q 0 g\n")
+	for row, cells := range matrix {
+		for col := 0; col < len(cells); {
+			if !cells[col] {
+				col++
+				continue
+			}
+			start := col
+			for col < len(cells) && cells[col] {
+				col++
+			}
+			fmt.Fprintf(b, "%.3f %.3f %.3f %.3f re\n",
+				mmToPt(x+float64(start)*module), mmToPt(top-float64(row+1)*module),
+				mmToPt(float64(col-start)*module), mmToPt(module))
+		}
+	}
+	b.WriteString("f Q\n")
+}
+
+// writePDF serializa as páginas, as fontes, o logotipo, os metadados e, no
+// PDF/A, o perfil de cor e os arquivos associados. As fontes são reduzidas
+// aos caracteres de charsets.
+func writePDF(out io.Writer, l pdfLayout, pages []string, logo *image.NRGBA, doc converter.Document, charsets [2]pdfCharset) error {
+	w := newPDFWriter("1.7")
+	catalog, pagesID := w.alloc(), w.alloc()
+	info, metadata := writeInfo(w, doc)
+	regular, err := writeFont(w, "GoMono", gomono.TTF, 0, charsets[0])
+	if err != nil {
+		return err
+	}
+	bold, err := writeFont(w, "GoMono-Bold", gomonobold.TTF, 1<<18, charsets[1])
+	if err != nil {
+		return err
+	}
+	fonts := []int{regular, bold}
+
+	resources := fmt.Sprintf("/Font << /F1 %d 0 R /F2 %d 0 R >>", fonts[0], fonts[1])
+	if logo != nil {
+		id := w.alloc()
+		writeImage(w, id, logo)
+		resources += fmt.Sprintf(" /XObject << /Logo %d 0 R >>", id)
+	}
+
+	var kids []string
+	for _, content := range pages {
+		pageID, contentID := w.alloc(), w.alloc()
+		w.stream(contentID, "", []byte(content))
+		w.object(pageID, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.3f %.3f] /Resources << %s >> /Contents %d 0 R >>",
+			pagesID, mmToPt(l.pageW), mmToPt(l.pageH), resources, contentID))
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageID))
+	}
+	w.object(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+
+	root := fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R /Metadata %d 0 R", pagesID, metadata)
+	if doc.PDFA != "" {
+		root += " /OutputIntents [" + writeOutputIntent(w) + "]"
+	}
+	if len(doc.Attachments) > 0 {
+		root += writeAttachments(w, doc.Attachments)
+	}
+	w.object(catalog, root+" >>")
+
+	return w.finish(out, catalog, info)
+}
+
+// writeFont embute a fonte TrueType com WinAnsiEncoding, reduzida aos glifos
+// de charset, e retorna o objeto da fonte. flags acrescenta bits ao
+// descritor (ForceBold).
+func writeFont(w *pdfWriter, name string, ttf []byte, flags int, charset pdfCharset) (int, error) {
+	runes := charset.runes()
+	ttf, err := subsetTrueType(ttf, runes)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao embutir a fonte %s: %w", name, err)
+	}
+	name = subsetTag(runes) + "+" + name
+	fontID, descriptorID, fileID := w.alloc(), w.alloc(), w.alloc()
+
+	w.stream(fileID, fmt.Sprintf("/Length1 %d", len(ttf)), ttf)
+	// Flags: FixedPitch (1) e Nonsymbolic (32)
+	w.object(descriptorID, fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags %d /FontBBox [-25 -211 603 1119] "+
+		"/ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		name, 1|32|flags, monoAscent, monoDescent, monoCapHeight, fileID))
+
+	widths := strings.TrimSpace(strings.Repeat("600 ", 256-32))
+	w.object(fontID, fmt.Sprintf("<< /Type /Font /Subtype /TrueType /BaseFont /%s /FirstChar 32 /LastChar 255 "+
+		"/Widths [%s] /Encoding /WinAnsiEncoding /FontDescriptor %d 0 R >>", name, widths, descriptorID))
+	return fontID, nil
+}
+
+// writeImage escreve o logotipo como imagem RGB sobre fundo branco
+func writeImage(w *pdfWriter, id int, img *image.NRGBA) {
+	b := img.Bounds()
+	rgb := make([]byte, 0, b.Dx()*b.Dy()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.NRGBAAt(x, y)
+			a := int(c.A)
+			blend := func(v uint8) byte { return byte((int(v)*a + 255*(255-a)) / 255) }
+			rgb = append(rgb, blend(c.R), blend(c.G), blend(c.B))
+		}
+	}
+	w.stream(id, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8",
+		b.Dx(), b.Dy()), rgb)
+}
+
+// pdfLogo decodifica o logotipo. Logotipos SVG não são desenhados.
+func pdfLogo(logo *Logo) (*image.NRGBA, error) {
+	if logo.mimeType != LogoPNG {
+		return nil, nil
+	}
+	img, err := png.Decode(bytes.NewReader(logo.data))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao decodificar logotipo: %w", err)
+	}
+	b := img.Bounds()
+	return resize(img, b.Dx(), b.Dy()), nil
+}
+
+// pdfDate formata a data no formato de datas do PDF
+func pdfDate(t time.Time) string {
+	_, offset := t.Zone()
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	return fmt.Sprintf("D:%s%c%02d'%02d'", t.Format("20060102150405"), sign, offset/3600, offset%3600/60)
+}
+
+// mmToPt converte milímetros em pontos tipográficos
+func mmToPt(mm float64) float64 {
+	return mm * 72 / 25.4
+}
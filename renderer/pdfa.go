@@ -0,0 +1,270 @@
+package renderer
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marcelo-cunha/nfce-render/converter"
+)
+
+// pdfProducer identifica a biblioteca nos metadados do PDF
+const pdfProducer = "nfce-render"
+
+// Document retorna a conformidade PDF/A, os metadados e, no PDF/A-3, o XML
+// original como anexo. O mesmo documento é usado pelo PDF nativo e enviado
+// aos conversores de HTML.
+func (r *PDFRenderer) Document() converter.Document {
+	return r.pdfDocument()
+}
+
+// pdfDocument monta o documento PDF: título com número e série, autor com o
+// emitente e palavras-chave com a chave de acesso e o SHA-256 do XML
+func (c *config) pdfDocument() converter.Document {
+	inf := c.nfe.NFe.InfNFe
+	chave := c.nfe.GetChaveAcesso()
+
+	keywords := []string{"NFC-e", "chave:" + chave}
+	if len(c.sourceXML) > 0 {
+		sum := sha256.Sum256(c.sourceXML)
+		keywords = append(keywords, "sha256:"+hex.EncodeToString(sum[:]))
+	}
+
+	doc := converter.Document{
+		PDFA: c.pdfa,
+		Metadata: converter.Metadata{
+			Title:        fmt.Sprintf("NFC-e nº %s Série %s", inf.Ide.NNF, inf.Ide.Serie),
+			Author:       inf.Emit.XNome,
+			Subject:      "Documento Auxiliar da Nota Fiscal de Consumidor Eletrônica",
+			Keywords:     keywords,
+			CreationDate: c.nfe.GetDataEmissao(),
+		},
+	}
+
+	if c.pdfa == converter.PDFA3B && len(c.sourceXML) > 0 {
+		name := chave + "-nfe.xml"
+		if c.nfe.ProtNFe.InfProt.NProt != "" {
+			name = chave + "-procNFe.xml"
+		}
+		doc.Attachments = []converter.Attachment{{
+			Name:        name,
+			MimeType:    "application/xml",
+			Description: "XML da NFC-e",
+			Data:        c.sourceXML,
+			ModDate:     c.nfe.GetDataEmissao(),
+		}}
+	}
+	return doc
+}
+
+// checkPDFA valida a conformidade e os anexos do documento
+func checkPDFA(doc converter.Document) error {
+	switch doc.PDFA {
+	case "", converter.PDFA3B:
+		return nil
+	case converter.PDFA2B:
+		if len(doc.Attachments) > 0 {
+			return fmt.Errorf("o PDF/A-2b não admite arquivos embutidos")
+		}
+		return nil
+	default:
+		return fmt.Errorf("conformidade PDF/A não suportada: %q", doc.PDFA)
+	}
+}
+
+// writeInfo escreve o dicionário de informações e os metadados XMP
+// equivalentes, como o PDF/A exige. Retorna os objetos Info e Metadata.
+func writeInfo(w *pdfWriter, doc converter.Document) (info, metadata int) {
+	info, metadata = w.alloc(), w.alloc()
+	meta := doc.Metadata
+	keywords := strings.Join(meta.Keywords, ", ")
+
+	var dict strings.Builder
+	dict.WriteString("<<")
+	for _, entry := range [][2]string{{"Title", meta.Title}, {"Author", meta.Author}, {"Subject", meta.Subject}, {"Keywords", keywords}} {
+		if entry[1] != "" {
+			fmt.Fprintf(&dict, " /%s %s", entry[0], pdfText(entry[1]))
+		}
+	}
+	fmt.Fprintf(&dict, " /Creator %s /Producer %s", pdfText(pdfProducer), pdfText(pdfProducer))
+	if !meta.CreationDate.IsZero() {
+		fmt.Fprintf(&dict, " /CreationDate %s", pdfText(pdfDate(meta.CreationDate)))
+	}
+	dict.WriteString(" >>")
+	w.object(info, dict.String())
+
+	var x strings.Builder
+	x.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	x.WriteString(`<x:xmpmeta xmlns:x="adobe:ns:meta/">` + "\n")
+	x.WriteString(`<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` + "\n")
+	x.WriteString(`<rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:xmp="http://ns.adobe.com/xap/1.0/" ` +
+		`xmlns:pdf="http://ns.adobe.com/pdf/1.3/" xmlns:pdfaid="http://www.aiim.org/pdfa/ns/id/">` + "\n")
+	x.WriteString("<dc:format>application/pdf</dc:format>\n")
+	if meta.Title != "" {
+		fmt.Fprintf(&x, "<dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:title>\n", xmlText(meta.Title))
+	}
+	if meta.Author != "" {
+		fmt.Fprintf(&x, "<dc:creator><rdf:Seq><rdf:li>%s</rdf:li></rdf:Seq></dc:creator>\n", xmlText(meta.Author))
+	}
+	if meta.Subject != "" {
+		fmt.Fprintf(&x, "<dc:description><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:description>\n", xmlText(meta.Subject))
+	}
+	if keywords != "" {
+		fmt.Fprintf(&x, "<pdf:Keywords>%s</pdf:Keywords>\n", xmlText(keywords))
+	}
+	fmt.Fprintf(&x, "<pdf:Producer>%s</pdf:Producer>\n<xmp:CreatorTool>%s</xmp:CreatorTool>\n", pdfProducer, pdfProducer)
+	if !meta.CreationDate.IsZero() {
+		fmt.Fprintf(&x, "<xmp:CreateDate>%s</xmp:CreateDate>\n", meta.CreationDate.Format(time.RFC3339))
+	}
+	if doc.PDFA != "" {
+		// "PDF/A-3b" vira part 3, conformance B
+		level := strings.TrimPrefix(string(doc.PDFA), "PDF/A-")
+		fmt.Fprintf(&x, "<pdfaid:part>%s</pdfaid:part>\n<pdfaid:conformance>%s</pdfaid:conformance>\n",
+			level[:1], strings.ToUpper(level[1:]))
+	}
+	x.WriteString("</rdf:Description>\n</rdf:RDF>\n</x:xmpmeta>\n<?xpacket end=\"w\"?>")
+	w.rawStream(metadata, "/Type /Metadata /Subtype /XML", []byte(x.String()))
+	return info, metadata
+}
+
+// writeOutputIntent escreve o perfil sRGB e retorna o OutputIntent do PDF/A
+func writeOutputIntent(w *pdfWriter) string {
+	id := w.alloc()
+	w.stream(id, "/N 3", sRGBProfile())
+	return fmt.Sprintf("<< /Type /OutputIntent /S /GTS_PDFA1 /OutputConditionIdentifier (sRGB IEC61966-2.1) "+
+		"/Info (sRGB IEC61966-2.1) /DestOutputProfile %d 0 R >>", id)
+}
+
+// writeAttachments embute os arquivos e retorna as entradas /Names e /AF do
+// catálogo
+func writeAttachments(w *pdfWriter, attachments []converter.Attachment) string {
+	// A árvore de nomes exige as chaves em ordem
+	sorted := append([]converter.Attachment(nil), attachments...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var names, specs []string
+	for _, a := range sorted {
+		fileID, specID := w.alloc(), w.alloc()
+		sum := md5.Sum(a.Data)
+		params := fmt.Sprintf("/Size %d /CheckSum <%x>", len(a.Data), sum)
+		if !a.ModDate.IsZero() {
+			params += " /ModDate " + pdfText(pdfDate(a.ModDate))
+		}
+		w.stream(fileID, fmt.Sprintf("/Type /EmbeddedFile /Subtype %s /Params << %s >>", pdfName(a.MimeType), params), a.Data)
+
+		spec := fmt.Sprintf("<< /Type /Filespec /F %s /UF %s /AFRelationship /Source /EF << /F %d 0 R /UF %d 0 R >>",
+			pdfText(a.Name), pdfText(a.Name), fileID, fileID)
+		if a.Description != "" {
+			spec += " /Desc " + pdfText(a.Description)
+		}
+		w.object(specID, spec+" >>")
+
+		names = append(names, fmt.Sprintf("%s %d 0 R", pdfText(a.Name), specID))
+		specs = append(specs, fmt.Sprintf("%d 0 R", specID))
+	}
+	return fmt.Sprintf(" /Names << /EmbeddedFiles << /Names [%s] >> >> /AF [%s]",
+		strings.Join(names, " "), strings.Join(specs, " "))
+}
+
+// xmlText escapa o texto para o XMP
+func xmlText(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+var (
+	sRGBOnce sync.Once
+	sRGBICC  []byte
+)
+
+// sRGBProfile retorna um perfil ICC v2 do espaço sRGB (IEC 61966-2.1), usado
+// como OutputIntent do PDF/A. O perfil é montado em código para não embutir
+// arquivos binários na biblioteca.
+func sRGBProfile() []byte {
+	sRGBOnce.Do(func() {
+		s15 := func(v float64) uint32 { return uint32(int32(math.Round(v * 65536))) }
+		xyz := func(x, y, z float64) []byte {
+			b := []byte("XYZ \x00\x00\x00\x00")
+			return binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(b, s15(x)), s15(y)), s15(z))
+		}
+
+		// Curva de transferência do sRGB em 1024 pontos
+		curve := binary.BigEndian.AppendUint32([]byte("curv\x00\x00\x00\x00"), 1024)
+		for i := 0; i < 1024; i++ {
+			v := float64(i) / 1023
+			if v <= 0.04045 {
+				v /= 12.92
+			} else {
+				v = math.Pow((v+0.055)/1.055, 2.4)
+			}
+			curve = binary.BigEndian.AppendUint16(curve, uint16(math.Round(v*65535)))
+		}
+
+		const name = "sRGB IEC61966-2.1"
+		desc := binary.BigEndian.AppendUint32([]byte("desc\x00\x00\x00\x00"), uint32(len(name)+1))
+		desc = append(desc, name+"\x00"...)
+		desc = append(desc, make([]byte, 4+4+2+1+67)...) // Unicode e ScriptCode vazios
+		cprt := []byte("text\x00\x00\x00\x00No copyright, use freely\x00")
+
+		tags := []struct {
+			sig  string
+			data []byte
+		}{
+			{"desc", desc},
+			{"cprt", cprt},
+			{"wtpt", xyz(0.9642, 1, 0.8249)},
+			{"rXYZ", xyz(0.4360747, 0.2225045, 0.0139322)},
+			{"gXYZ", xyz(0.3850649, 0.7168786, 0.0971045)},
+			{"bXYZ", xyz(0.1430804, 0.0606169, 0.7141733)},
+			{"rTRC", curve},
+			{"gTRC", curve},
+			{"bTRC", curve},
+		}
+
+		// Cabeçalho de 128 bytes, tabela de tags e dados alinhados em 4 bytes.
+		// As três curvas compartilham os mesmos dados.
+		var data bytes.Buffer
+		table := binary.BigEndian.AppendUint32(nil, uint32(len(tags)))
+		offset := 128 + 4 + 12*len(tags)
+		shared := map[*byte]int{}
+		for _, tag := range tags {
+			start, ok := shared[&tag.data[0]]
+			if !ok {
+				start = offset + data.Len()
+				shared[&tag.data[0]] = start
+				data.Write(tag.data)
+				for data.Len()%4 != 0 {
+					data.WriteByte(0)
+				}
+			}
+			table = append(table, tag.sig...)
+			table = binary.BigEndian.AppendUint32(table, uint32(start))
+			table = binary.BigEndian.AppendUint32(table, uint32(len(tag.data)))
+		}
+
+		header := make([]byte, 128)
+		binary.BigEndian.PutUint32(header[0:], uint32(offset+data.Len()))
+		binary.BigEndian.PutUint32(header[8:], 0x02100000) // versão 2.1
+		copy(header[12:], "mntrRGB XYZ ")
+		for i, v := range []uint16{2024, 1, 1} { // data de criação fixa
+			binary.BigEndian.PutUint16(header[24+2*i:], v)
+		}
+		copy(header[36:], "acsp")
+		binary.BigEndian.PutUint32(header[68:], s15(0.9642)) // iluminante D50
+		binary.BigEndian.PutUint32(header[72:], s15(1))
+		binary.BigEndian.PutUint32(header[76:], s15(0.8249))
+
+		sRGBICC = append(append(header, table...), data.Bytes()...)
+	})
+	return sRGBICC
+}
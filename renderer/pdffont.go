@@ -0,0 +1,343 @@
+package renderer
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"sort"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+// pdfCharset registra os códigos WinAnsi usados com uma fonte
+type pdfCharset [256]bool
+
+// add marca os códigos do texto já codificado em WinAnsi
+func (c *pdfCharset) add(text []byte) {
+	for _, b := range text {
+		c[b] = true
+	}
+}
+
+// runes retorna os caracteres Unicode dos códigos usados, em ordem
+func (c *pdfCharset) runes() []rune {
+	var runes []rune
+	for code, used := range c {
+		if !used {
+			continue
+		}
+		r := rune(code)
+		for extra, b := range winAnsiExtra {
+			if int(b) == code {
+				r = extra
+			}
+		}
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	return runes
+}
+
+// subsetTag retorna o prefixo de seis letras que identifica o subconjunto
+// de glifos no nome da fonte, como em ABCDEF+GoMono
+func subsetTag(runes []rune) string {
+	sum := sha256.Sum256([]byte(string(runes)))
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = 'A' + sum[i]%26
+	}
+	return string(tag)
+}
+
+// Tabelas mantidas no subconjunto. O name é mantido por conter a licença da
+// fonte; GSUB, GPOS, kern e afins não são usados pelo PDF.
+var subsetTables = []string{"OS/2", "cmap", "cvt ", "fpgm", "gasp", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "name", "post", "prep"}
+
+// subsetTrueType reduz a fonte TrueType aos glifos dos caracteres informados
+// e aos componentes dos glifos compostos. Os índices dos glifos não mudam:
+// os demais ficam vazios, o hmtx é mantido e o cmap passa a mapear apenas os
+// caracteres informados.
+func subsetTrueType(ttf []byte, runes []rune) ([]byte, error) {
+	tables, err := readTables(ttf)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range []string{"cmap", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "post"} {
+		if tables[tag] == nil {
+			return nil, fmt.Errorf("fonte sem a tabela %s", tag)
+		}
+	}
+	head, maxp, post := tables["head"], tables["maxp"], tables["post"]
+	if len(head) < 54 || len(maxp) < 6 || len(post) < 32 {
+		return nil, fmt.Errorf("fonte com tabelas truncadas")
+	}
+
+	numGlyphs := int(binary.BigEndian.Uint16(maxp[4:]))
+	glyphs, err := readGlyphs(tables["loca"], tables["glyf"], numGlyphs, binary.BigEndian.Uint16(head[50:]) == 1)
+	if err != nil {
+		return nil, err
+	}
+
+	// Glifos dos caracteres, além do .notdef
+	font, err := sfnt.Parse(ttf)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler a fonte: %w", err)
+	}
+	var buf sfnt.Buffer
+	keep := map[int]bool{0: true}
+	var mapping [][2]int
+	for _, r := range runes {
+		gid, err := font.GlyphIndex(&buf, r)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler a fonte: %w", err)
+		}
+		if gid != 0 {
+			mapping = append(mapping, [2]int{int(r), int(gid)})
+			keep[int(gid)] = true
+		}
+	}
+	queue := make([]int, 0, len(keep))
+	for gid := range keep {
+		queue = append(queue, gid)
+	}
+	for len(queue) > 0 {
+		gid := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		components, err := glyphComponents(glyphs[gid])
+		if err != nil {
+			return nil, fmt.Errorf("glifo %d: %w", gid, err)
+		}
+		for _, c := range components {
+			if c >= numGlyphs {
+				return nil, fmt.Errorf("glifo %d: componente %d inexistente", gid, c)
+			}
+			if !keep[c] {
+				keep[c] = true
+				queue = append(queue, c)
+			}
+		}
+	}
+
+	// glyf com os glifos mantidos e loca no formato longo
+	var glyf []byte
+	loca := make([]byte, 0, 4*(numGlyphs+1))
+	for gid, data := range glyphs {
+		loca = binary.BigEndian.AppendUint32(loca, uint32(len(glyf)))
+		if keep[gid] {
+			glyf = append(glyf, data...)
+			glyf = append(glyf, make([]byte, (4-len(data)%4)%4)...)
+		}
+	}
+	loca = binary.BigEndian.AppendUint32(loca, uint32(len(glyf)))
+
+	head = append([]byte(nil), head...)
+	binary.BigEndian.PutUint32(head[8:], 0) // checkSumAdjustment
+	binary.BigEndian.PutUint16(head[50:], 1)
+
+	// post no formato 3, sem os nomes dos glifos
+	post = append([]byte(nil), post[:32]...)
+	binary.BigEndian.PutUint32(post, 0x00030000)
+
+	tables["glyf"], tables["loca"], tables["head"], tables["post"] = glyf, loca, head, post
+	tables["cmap"] = buildCmap(mapping)
+
+	out := writeTables(binary.BigEndian.Uint32(ttf), tables)
+	binary.BigEndian.PutUint32(out[headOffset(out):][8:], 0xB1B0AFBA-tableChecksum(out))
+	return out, nil
+}
+
+// readTables lê o diretório de tabelas da fonte, mantendo as de subsetTables
+func readTables(ttf []byte) (map[string][]byte, error) {
+	if len(ttf) < 12 {
+		return nil, fmt.Errorf("fonte truncada")
+	}
+	tables := map[string][]byte{}
+	n := int(binary.BigEndian.Uint16(ttf[4:]))
+	if len(ttf) < 12+16*n {
+		return nil, fmt.Errorf("fonte truncada")
+	}
+	for i := 0; i < n; i++ {
+		entry := ttf[12+16*i:]
+		offset, length := binary.BigEndian.Uint32(entry[8:]), binary.BigEndian.Uint32(entry[12:])
+		if uint64(offset)+uint64(length) > uint64(len(ttf)) {
+			return nil, fmt.Errorf("tabela %s fora da fonte", entry[:4])
+		}
+		tables[string(entry[:4])] = ttf[offset : offset+length]
+	}
+	for tag := range tables {
+		if !slices.Contains(subsetTables, tag) {
+			delete(tables, tag)
+		}
+	}
+	return tables, nil
+}
+
+// readGlyphs separa os glifos do glyf pelos deslocamentos do loca
+func readGlyphs(loca, glyf []byte, numGlyphs int, long bool) ([][]byte, error) {
+	offset := func(i int) int {
+		if long {
+			return int(binary.BigEndian.Uint32(loca[4*i:]))
+		}
+		return 2 * int(binary.BigEndian.Uint16(loca[2*i:]))
+	}
+	size := 2
+	if long {
+		size = 4
+	}
+	if len(loca) < size*(numGlyphs+1) {
+		return nil, fmt.Errorf("tabela loca truncada")
+	}
+	glyphs := make([][]byte, numGlyphs)
+	for i := range glyphs {
+		start, end := offset(i), offset(i+1)
+		if start > end || end > len(glyf) {
+			return nil, fmt.Errorf("glifo %d fora da tabela glyf", i)
+		}
+		glyphs[i] = glyf[start:end]
+	}
+	return glyphs, nil
+}
+
+// glyphComponents retorna os glifos usados por um glifo composto
+func glyphComponents(glyph []byte) ([]int, error) {
+	if len(glyph) < 10 || int16(binary.BigEndian.Uint16(glyph)) >= 0 {
+		return nil, nil
+	}
+	const (
+		argsAreWords   = 0x0001
+		haveScale      = 0x0008
+		moreComponents = 0x0020
+		haveXYScale    = 0x0040
+		haveTwoByTwo   = 0x0080
+	)
+	var components []int
+	for p := 10; ; {
+		if p+4 > len(glyph) {
+			return nil, fmt.Errorf("glifo composto truncado")
+		}
+		flags := binary.BigEndian.Uint16(glyph[p:])
+		components = append(components, int(binary.BigEndian.Uint16(glyph[p+2:])))
+		p += 4
+		if flags&argsAreWords != 0 {
+			p += 4
+		} else {
+			p += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			p += 2
+		case flags&haveXYScale != 0:
+			p += 4
+		case flags&haveTwoByTwo != 0:
+			p += 8
+		}
+		if flags&moreComponents == 0 {
+			return components, nil
+		}
+	}
+}
+
+// buildCmap monta um cmap com uma única subtabela (3, 1) no formato 4 para
+// os pares caractere e glifo informados, em ordem de caractere
+func buildCmap(mapping [][2]int) []byte {
+	// Caracteres consecutivos com glifos consecutivos formam um segmento
+	type segment struct{ start, end, delta int }
+	var segments []segment
+	for _, m := range mapping {
+		if n := len(segments); n > 0 && segments[n-1].end+1 == m[0] && segments[n-1].delta == m[1]-m[0] {
+			segments[n-1].end = m[0]
+			continue
+		}
+		segments = append(segments, segment{m[0], m[0], m[1] - m[0]})
+	}
+	segments = append(segments, segment{0xFFFF, 0xFFFF, 1})
+
+	segCount := len(segments)
+	searchRange, entrySelector := 2, 0
+	for searchRange*2 <= 2*segCount {
+		searchRange *= 2
+		entrySelector++
+	}
+
+	sub := binary.BigEndian.AppendUint16(nil, 4)
+	sub = binary.BigEndian.AppendUint16(sub, uint16(16+8*segCount))
+	sub = binary.BigEndian.AppendUint16(sub, 0) // language
+	sub = binary.BigEndian.AppendUint16(sub, uint16(2*segCount))
+	sub = binary.BigEndian.AppendUint16(sub, uint16(searchRange))
+	sub = binary.BigEndian.AppendUint16(sub, uint16(entrySelector))
+	sub = binary.BigEndian.AppendUint16(sub, uint16(2*segCount-searchRange))
+	for _, s := range segments {
+		sub = binary.BigEndian.AppendUint16(sub, uint16(s.end))
+	}
+	sub = binary.BigEndian.AppendUint16(sub, 0) // reservedPad
+	for _, s := range segments {
+		sub = binary.BigEndian.AppendUint16(sub, uint16(s.start))
+	}
+	for _, s := range segments {
+		sub = binary.BigEndian.AppendUint16(sub, uint16(s.delta))
+	}
+	for range segments {
+		sub = binary.BigEndian.AppendUint16(sub, 0) // idRangeOffset
+	}
+
+	cmap := []byte{0, 0, 0, 1, 0, 3, 0, 1, 0, 0, 0, 12}
+	return append(cmap, sub...)
+}
+
+// writeTables serializa a fonte com as tabelas em ordem de tag
+func writeTables(version uint32, tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	n := len(tags)
+	searchRange, entrySelector := 1, 0
+	for searchRange*2 <= n {
+		searchRange *= 2
+		entrySelector++
+	}
+	out := binary.BigEndian.AppendUint32(nil, version)
+	out = binary.BigEndian.AppendUint16(out, uint16(n))
+	out = binary.BigEndian.AppendUint16(out, uint16(16*searchRange))
+	out = binary.BigEndian.AppendUint16(out, uint16(entrySelector))
+	out = binary.BigEndian.AppendUint16(out, uint16(16*(n-searchRange)))
+
+	offset := 12 + 16*n
+	var data []byte
+	for _, tag := range tags {
+		table := tables[tag]
+		out = append(out, tag...)
+		out = binary.BigEndian.AppendUint32(out, tableChecksum(table))
+		out = binary.BigEndian.AppendUint32(out, uint32(offset+len(data)))
+		out = binary.BigEndian.AppendUint32(out, uint32(len(table)))
+		data = append(data, table...)
+		data = append(data, make([]byte, (4-len(table)%4)%4)...)
+	}
+	return append(out, data...)
+}
+
+// headOffset retorna a posição da tabela head na fonte serializada
+func headOffset(ttf []byte) int {
+	n := int(binary.BigEndian.Uint16(ttf[4:]))
+	for i := 0; i < n; i++ {
+		entry := ttf[12+16*i:]
+		if string(entry[:4]) == "head" {
+			return int(binary.BigEndian.Uint32(entry[8:]))
+		}
+	}
+	return 0
+}
+
+// tableChecksum soma os dados em palavras de 32 bits, completando com zeros
+func tableChecksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
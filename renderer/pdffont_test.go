@@ -0,0 +1,120 @@
+package renderer
+
+import (
+	"bytes"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+func TestSubsetTrueType(t *testing.T) {
+	var charset pdfCharset
+	charset.add(winAnsi("NFC-e nº 123 – Emissão: ÁÉÍÓÚ çãõ € R$ 1.234,56"))
+	runes := charset.runes()
+
+	for name, ttf := range map[string][]byte{"GoMono": gomono.TTF, "GoMono-Bold": gomonobold.TTF} {
+		t.Run(name, func(t *testing.T) {
+			subset, err := subsetTrueType(ttf, runes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(subset) > len(ttf)/4 {
+				t.Errorf("subconjunto com %d bytes, fonte completa com %d", len(subset), len(ttf))
+			}
+			if sum := tableChecksum(subset); sum != 0xB1B0AFBA {
+				t.Errorf("checksum da fonte %#x", sum)
+			}
+
+			full, err := sfnt.Parse(ttf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reduced, err := sfnt.Parse(subset)
+			if err != nil {
+				t.Fatalf("subconjunto inválido: %v", err)
+			}
+			var buf sfnt.Buffer
+			ppem := fixed.I(1000)
+			for _, r := range runes {
+				gid, err := full.GlyphIndex(&buf, r)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got, _ := reduced.GlyphIndex(&buf, r); got != gid {
+					t.Errorf("%q: glifo %d, esperado %d", r, got, gid)
+					continue
+				}
+				want, err := full.LoadGlyph(&buf, gid, ppem, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want = append(sfnt.Segments(nil), want...)
+				got, err := reduced.LoadGlyph(&buf, gid, ppem, nil)
+				if err != nil {
+					t.Fatalf("%q: %v", r, err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("%q: contorno diferente do original", r)
+				}
+				wantAdvance, _ := full.GlyphAdvance(&buf, gid, ppem, font.HintingNone)
+				if advance, _ := reduced.GlyphAdvance(&buf, gid, ppem, font.HintingNone); advance != wantAdvance {
+					t.Errorf("%q: avanço %v, esperado %v", r, advance, wantAdvance)
+				}
+			}
+
+			if gid, _ := reduced.GlyphIndex(&buf, 'Z'); gid != 0 {
+				t.Errorf("caractere fora do subconjunto mapeado para o glifo %d", gid)
+			}
+		})
+	}
+}
+
+func TestPDFRendererSubsetsFonts(t *testing.T) {
+	render := func() []byte {
+		var buf bytes.Buffer
+		if err := NewPDFRenderer(sampleNFe()).RenderToWriter(&buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	pdf := render()
+
+	fonts := regexp.MustCompile(`/BaseFont /([A-Z]{6})\+(GoMono(-Bold)?) `).FindAllSubmatch(pdf, -1)
+	if len(fonts) != 2 {
+		t.Fatalf("esperadas 2 fontes com prefixo de subconjunto, obtidas %d", len(fonts))
+	}
+	if bytes.Equal(fonts[0][1], fonts[1][1]) {
+		t.Errorf("mesmo prefixo %s para caracteres diferentes", fonts[0][1])
+	}
+	if len(pdf) > len(gomono.TTF)/2 {
+		t.Errorf("PDF com %d bytes", len(pdf))
+	}
+	if !bytes.Equal(pdf, render()) {
+		t.Error("saída não determinística")
+	}
+}
+
+func TestGlyphComponents(t *testing.T) {
+	// Glifo composto: cabeçalho com numberOfContours -1, um componente com
+	// argumentos de 16 bits e outro com escala
+	glyph := []byte{0xFF, 0xFF, 0, 0, 0, 0, 0, 0, 0, 0}
+	glyph = append(glyph, 0x00, 0x21, 0, 5, 0, 1, 0, 2)
+	glyph = append(glyph, 0x00, 0x08, 0, 7, 1, 2, 0x40, 0)
+
+	components, err := glyphComponents(glyph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(components, []int{5, 7}) {
+		t.Errorf("componentes %v, esperados [5 7]", components)
+	}
+	if _, err := glyphComponents(glyph[:len(glyph)-6]); err == nil {
+		t.Error("glifo composto truncado aceito")
+	}
+}
@@ -0,0 +1,157 @@
+package renderer
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// pdfWriter serializa objetos PDF e monta a tabela de referências cruzadas.
+// A saída depende apenas do conteúdo: não há datas nem identificadores
+// aleatórios.
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets []int // posição de cada objeto, indexada pelo número - 1
+}
+
+// newPDFWriter inicia o arquivo com o cabeçalho da versão informada
+func newPDFWriter(version string) *pdfWriter {
+	w := &pdfWriter{}
+	// O comentário binário indica aos leitores que o arquivo contém bytes 8 bits
+	w.buf.WriteString("%PDF-" + version + "\n%\xE2\xE3\xCF\xD3\n")
+	return w
+}
+
+// alloc reserva o número de um objeto que será escrito depois
+func (w *pdfWriter) alloc() int {
+	w.offsets = append(w.offsets, -1)
+	return len(w.offsets)
+}
+
+// object escreve o objeto id com o dicionário ou valor informado
+func (w *pdfWriter) object(id int, body string) {
+	w.offsets[id-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+// stream escreve o objeto id como stream comprimido com FlateDecode. dict
+// contém as entradas adicionais do dicionário, sem os delimitadores.
+func (w *pdfWriter) stream(id int, dict string, data []byte) {
+	var compressed bytes.Buffer
+	zw, _ := zlib.NewWriterLevel(&compressed, zlib.BestCompression)
+	zw.Write(data)
+	zw.Close()
+
+	w.rawStream(id, dict+" /Filter /FlateDecode", compressed.Bytes())
+}
+
+// rawStream escreve o objeto id como stream sem filtro, como o PDF/A exige
+// para os metadados XMP
+func (w *pdfWriter) rawStream(id int, dict string, data []byte) {
+	w.offsets[id-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< %s /Length %d >>\nstream\n", id, dict, len(data))
+	w.buf.Write(data)
+	w.buf.WriteString("\nendstream\nendobj\n")
+}
+
+// finish escreve a tabela xref e o trailer. O /ID é derivado do conteúdo,
+// o que mantém a saída determinística.
+func (w *pdfWriter) finish(out io.Writer, root, info int) error {
+	sum := sha256.Sum256(w.buf.Bytes())
+	id := hex.EncodeToString(sum[:16])
+
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for i, off := range w.offsets {
+		if off < 0 {
+			return fmt.Errorf("objeto PDF %d não foi escrito", i+1)
+		}
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R /ID [<%s> <%s>] >>\nstartxref\n%d\n%%%%EOF\n",
+		len(w.offsets)+1, root, info, id, id, xref)
+
+	_, err := out.Write(w.buf.Bytes())
+	return err
+}
+
+// pdfText codifica o texto como string PDF, usada no dicionário de
+// informações: ASCII como string literal e os demais em UTF-16BE
+func pdfText(s string) string {
+	ascii := true
+	for _, r := range s {
+		if r >= 0x80 {
+			ascii = false
+			break
+		}
+	}
+	if !ascii {
+		var b strings.Builder
+		b.WriteString("<FEFF")
+		for _, u := range utf16.Encode([]rune(s)) {
+			fmt.Fprintf(&b, "%04X", u)
+		}
+		b.WriteString(">")
+		return b.String()
+	}
+
+	var b strings.Builder
+	b.WriteByte('(')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '(', ')', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// pdfName codifica s como nome PDF, escapando com #xx os delimitadores, os
+// espaços e os bytes fora da faixa imprimível
+func pdfName(s string) string {
+	var b strings.Builder
+	b.WriteByte('/')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < '!' || c > '~' || strings.IndexByte("#()<>[]{}/%", c) >= 0 {
+			fmt.Fprintf(&b, "#%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// winAnsi converte o texto para WinAnsiEncoding, a codificação das fontes
+// embutidas. Caracteres sem representação são trocados por "?".
+func winAnsi(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r < 0x80 || (r >= 0xA0 && r <= 0xFF):
+			out = append(out, byte(r))
+		case winAnsiExtra[r] != 0:
+			out = append(out, winAnsiExtra[r])
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
+
+// winAnsiExtra mapeia os caracteres da faixa 0x80-0x9F da WinAnsiEncoding
+var winAnsiExtra = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B,
+	'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
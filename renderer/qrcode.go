@@ -0,0 +1,161 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// QRCodeLevel é o nível de correção de erro do QR Code
+type QRCodeLevel int
+
+const (
+	QRCodeMedium  QRCodeLevel = iota // recupera 15% dos dados (padrão)
+	QRCodeLow                        // recupera 7% dos dados
+	QRCodeHigh                       // recupera 25% dos dados
+	QRCodeHighest                    // recupera 30% dos dados
+)
+
+// QRCodeOptions define como o QR Code do DANFE é gerado
+type QRCodeOptions struct {
+	// Level é o nível de correção de erro
+	Level QRCodeLevel
+	// QuietZone é a margem branca em módulos (padrão: 4, o mínimo da norma).
+	// Valores negativos removem a margem.
+	QuietZone int
+	// SizeMM é o lado impresso do QR Code, margem incluída
+	// (padrão: QRCodeMM do papel)
+	SizeMM float64
+	// PNG gera o QR Code como imagem PNG em base64 no lugar de SVG, para
+	// destinos que não exibem SVG
+	PNG bool
+}
+
+// qrCodePNGModule é o lado de cada módulo, em pixels, nas imagens PNG
+const qrCodePNGModule = 8
+
+// recoveryLevel converte o nível para a biblioteca de QR Code
+func (l QRCodeLevel) recoveryLevel() qrcode.RecoveryLevel {
+	switch l {
+	case QRCodeLow:
+		return qrcode.Low
+	case QRCodeHigh:
+		return qrcode.High
+	case QRCodeHighest:
+		return qrcode.Highest
+	default:
+		return qrcode.Medium
+	}
+}
+
+// qrMatrix codifica o conteúdo e retorna a matriz de módulos com a margem
+func qrMatrix(content string, opts QRCodeOptions) ([][]bool, error) {
+	q, err := qrcode.New(content, opts.Level.recoveryLevel())
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gerar QR Code: %w", err)
+	}
+	q.DisableBorder = true
+	bitmap := q.Bitmap()
+
+	quiet := opts.QuietZone
+	switch {
+	case quiet == 0:
+		quiet = 4
+	case quiet < 0:
+		quiet = 0
+	}
+
+	size := len(bitmap) + 2*quiet
+	matrix := make([][]bool, size)
+	for y := range matrix {
+		matrix[y] = make([]bool, size)
+		if y >= quiet && y < quiet+len(bitmap) {
+			copy(matrix[y][quiet:], bitmap[y-quiet])
+		}
+	}
+	return matrix, nil
+}
+
+// qrCodeSVG gera o QR Code como SVG com o lado sizeMM. Módulos vizinhos da
+// mesma linha são unidos em um único retângulo.
+func qrCodeSVG(content string, opts QRCodeOptions, sizeMM float64) (string, error) {
+	matrix, err := qrMatrix(content, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var path strings.Builder
+	for y, row := range matrix {
+		for x := 0; x < len(row); {
+			if !row[x] {
+				x++
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+
+	n := len(matrix)
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%gmm" height="%gmm" shape-rendering="crispEdges" role="img" aria-label="QR Code">`+
+		`<rect width="%d" height="%d" fill="#fff"/><path d="%s" fill="#000"/></svg>`,
+		n, n, sizeMM, sizeMM, n, n, path.String()), nil
+}
+
+// qrCodePNG gera o QR Code como PNG de 1 bit, com módulos de
+// qrCodePNGModule pixels
+func qrCodePNG(content string, opts QRCodeOptions) ([]byte, error) {
+	matrix, err := qrMatrix(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(matrix) * qrCodePNGModule
+	img := image.NewPaletted(image.Rect(0, 0, n, n), color.Palette{color.White, color.Black})
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if matrix[y/qrCodePNGModule][x/qrCodePNGModule] {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("erro ao codificar QR Code: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// generateQRCodeHTML gera o QR Code para o HTML: SVG inline por padrão ou
+// PNG em base64 quando opts.PNG
+func generateQRCodeHTML(content string, opts QRCodeOptions, sizeMM float64) template.HTML {
+	if content == "" {
+		return template.HTML("")
+	}
+
+	if !opts.PNG {
+		svg, err := qrCodeSVG(content, opts, sizeMM)
+		if err != nil {
+			return template.HTML("")
+		}
+		return template.HTML(svg)
+	}
+
+	pngBytes, err := qrCodePNG(content, opts)
+	if err != nil {
+		return template.HTML("")
+	}
+	base64String := base64.StdEncoding.EncodeToString(pngBytes)
+	return template.HTML(fmt.Sprintf(`<img src="data:image/png;base64,%s" alt="QR Code" style="width: %gmm; height: %gmm;">`, base64String, sizeMM, sizeMM))
+}
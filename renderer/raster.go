@@ -0,0 +1,332 @@
+package renderer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/marcelo-cunha/nfce-render/xmlparser"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Larguras de impressão das impressoras térmicas, em pontos
+const (
+	Dots384 = 384 // bobina de 58mm a 203 dpi
+	Dots576 = 576 // bobina de 80mm a 203 dpi (72mm de área útil)
+	Dots640 = 640 // bobina de 80mm a 203 dpi (80mm de área útil)
+)
+
+// RasterOptions define a resolução e a largura da imagem do DANFE
+type RasterOptions struct {
+	// DPI é a resolução da impressora: 203 ou 300 (padrão: 203)
+	DPI int
+	// Dots é a largura da imagem em pontos (padrão: 384 no papel de 58mm e
+	// 576 nos demais)
+	Dots int
+}
+
+// RasterRenderer desenha o DANFE como imagem, sem HTML nem conversor externo.
+// O texto usa a fonte Go Mono embutida e o QR Code é desenhado módulo a módulo.
+type RasterRenderer struct {
+	config
+}
+
+// NewRasterRenderer cria uma nova instância do renderizador de imagem
+func NewRasterRenderer(nfe *xmlparser.NFeProc, opts ...Option) *RasterRenderer {
+	return &RasterRenderer{
+		config: newConfig(nfe, opts),
+	}
+}
+
+// RenderPNG escreve o DANFE como PNG em tons de cinza
+func (r *RasterRenderer) RenderPNG(writer io.Writer) error {
+	img, err := r.Render()
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(writer, img); err != nil {
+		return fmt.Errorf("erro ao codificar PNG: %w", err)
+	}
+	return nil
+}
+
+// RenderPBM escreve o DANFE como PBM binário (P4) de 1 bit
+func (r *RasterRenderer) RenderPBM(writer io.Writer) error {
+	img, err := r.Render()
+	if err != nil {
+		return err
+	}
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+
+	bw := bufio.NewWriter(writer)
+	fmt.Fprintf(bw, "P4\n%d %d\n", w, h)
+	for y := 0; y < h; y++ {
+		bw.Write(packRow(img, y, true))
+	}
+	return bw.Flush()
+}
+
+// RenderBMP escreve o DANFE como BMP de 1 bit
+func (r *RasterRenderer) RenderBMP(writer io.Writer) error {
+	img, err := r.Render()
+	if err != nil {
+		return err
+	}
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	stride := ((w + 31) / 32) * 4 // linhas alinhadas em 4 bytes
+	const headerSize = 14 + 40 + 8
+	ppm := uint32(math.Round(float64(r.rasterDPI()) / 0.0254))
+
+	var buf bytes.Buffer
+	// Cabeçalho do arquivo
+	buf.WriteString("BM")
+	binary.Write(&buf, binary.LittleEndian, []uint32{uint32(headerSize + stride*h), 0, headerSize})
+	// BITMAPINFOHEADER
+	binary.Write(&buf, binary.LittleEndian, []uint32{40, uint32(w), uint32(h)})
+	binary.Write(&buf, binary.LittleEndian, []uint16{1, 1})
+	binary.Write(&buf, binary.LittleEndian, []uint32{0, uint32(stride * h), ppm, ppm, 2, 2})
+	// Paleta: índice 0 preto, índice 1 branco
+	buf.Write([]byte{0, 0, 0, 0, 0xFF, 0xFF, 0xFF, 0})
+
+	// As linhas são gravadas de baixo para cima
+	for y := h - 1; y >= 0; y-- {
+		row := packRow(img, y, false)
+		buf.Write(row)
+		buf.Write(make([]byte, stride-len(row)))
+	}
+
+	_, err = writer.Write(buf.Bytes())
+	return err
+}
+
+// packRow empacota uma linha da imagem em 1 bit por pixel. Com blackIsOne o
+// bit 1 representa preto (PBM); sem, representa branco (BMP).
+func packRow(img *image.Gray, y int, blackIsOne bool) []byte {
+	w := img.Bounds().Dx()
+	row := make([]byte, (w+7)/8)
+	for x := 0; x < w; x++ {
+		black := img.GrayAt(x, y).Y < 128
+		if black == blackIsOne {
+			row[x/8] |= 0x80 >> (x % 8)
+		}
+	}
+	return row
+}
+
+// Render desenha o DANFE e retorna a imagem em tons de cinza
+func (r *RasterRenderer) Render() (*image.Gray, error) {
+	dots := r.rasterDots()
+	columns := r.textColumns()
+	if r.columns == 0 && dots <= Dots384 {
+		columns = Columns32
+	}
+	cell := dots / columns
+	if cell < 4 {
+		return nil, fmt.Errorf("largura de %d pontos insuficiente para %d colunas", dots, columns)
+	}
+
+	faces, err := monoFaces(cell)
+	if err != nil {
+		return nil, err
+	}
+	metrics := faces.regular.Metrics()
+	ascent := metrics.Ascent.Ceil()
+	lineHeight := ascent + metrics.Descent.Ceil() + cell/4
+	margin := (dots - cell*columns) / 2
+
+	var logo *image.Paletted
+	if r.logo != nil {
+		if logo, err = r.rasterLogo(dots); err != nil {
+			return nil, err
+		}
+	}
+
+	// Cada linha do cupom vira uma faixa da imagem
+	var bands []image.Image
+	for _, line := range r.receipt(columns) {
+		switch line.kind {
+		case lineQRCode:
+			band, err := r.qrCodeBand(line.text, dots, lineHeight)
+			if err != nil {
+				return nil, err
+			}
+			bands = append(bands, band)
+		case lineLogo:
+			if logo != nil {
+				band := newBand(dots, logo.Bounds().Dy()+lineHeight/2)
+				x := (dots - logo.Bounds().Dx()) / 2
+				draw.Draw(band, logo.Bounds().Add(image.Pt(x, 0)), logo, image.Point{}, draw.Src)
+				bands = append(bands, band)
+			}
+		case lineCut:
+			band := newBand(dots, lineHeight*3)
+			for x := 0; x < dots; x++ {
+				if x%cell < cell/2 {
+					band.SetGray(x, lineHeight*3/2, color.Gray{})
+				}
+			}
+			bands = append(bands, band)
+		default:
+			face := faces.regular
+			if line.bold {
+				face = faces.bold
+			}
+			band := newBand(dots, lineHeight)
+			text := []rune(alignText(line.text, line.align, columns))
+			d := font.Drawer{Dst: band, Src: image.Black, Face: face}
+			for i, ch := range text {
+				if ch == ' ' {
+					continue
+				}
+				d.Dot = fixed.P(margin+i*cell, ascent+cell/8)
+				d.DrawString(string(ch))
+			}
+			if line.large {
+				band = doubleHeight(band)
+			}
+			bands = append(bands, band)
+		}
+	}
+
+	// Margem final para o corte do papel
+	bands = append(bands, newBand(dots, lineHeight*2))
+
+	height := 0
+	for _, band := range bands {
+		height += band.Bounds().Dy()
+	}
+	img := newBand(dots, height)
+	y := 0
+	for _, band := range bands {
+		b := band.Bounds()
+		draw.Draw(img, image.Rect(0, y, dots, y+b.Dy()), band, b.Min, draw.Src)
+		y += b.Dy()
+	}
+	return img, nil
+}
+
+// rasterDPI retorna a resolução configurada
+func (c *config) rasterDPI() int {
+	if c.raster.DPI > 0 {
+		return c.raster.DPI
+	}
+	return 203
+}
+
+// rasterDots retorna a largura da imagem configurada ou a padrão do papel
+func (c *config) rasterDots() int {
+	if c.raster.Dots > 0 {
+		return c.raster.Dots
+	}
+	if c.paper.ContentWidthMM > 0 && c.paper.ContentWidthMM < 70 {
+		return Dots384
+	}
+	return Dots576
+}
+
+// qrCodeBand desenha o QR Code centralizado, com módulos de tamanho inteiro
+// para que a impressão fique nítida
+func (c *config) qrCodeBand(content string, dots, padding int) (*image.Gray, error) {
+	matrix, err := qrMatrix(content, c.qrcode)
+	if err != nil {
+		return nil, err
+	}
+	n := len(matrix)
+	target := int(c.paper.QRCodeMM / 25.4 * float64(c.rasterDPI()))
+	module := max(1, min(target, dots)/n)
+
+	band := newBand(dots, n*module+padding)
+	x0 := (dots - n*module) / 2
+	for y, row := range matrix {
+		for x, dark := range row {
+			if dark {
+				rect := image.Rect(x0+x*module, y*module, x0+(x+1)*module, (y+1)*module)
+				draw.Draw(band, rect, image.Black, image.Point{}, draw.Src)
+			}
+		}
+	}
+	return band, nil
+}
+
+// rasterLogo ajusta o logotipo à resolução e à largura da imagem. Logotipos
+// SVG não são desenhados.
+func (c *config) rasterLogo(dots int) (*image.Paletted, error) {
+	if c.logo.mimeType != LogoPNG {
+		return nil, nil
+	}
+	img, err := png.Decode(bytes.NewReader(c.logo.data))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao decodificar logotipo: %w", err)
+	}
+	w := min(dots, mmToPixels(c.logo.widthMM, c.rasterDPI()))
+	h := max(1, int(math.Round(float64(img.Bounds().Dy())*float64(w)/float64(img.Bounds().Dx()))))
+	return dither(resize(img, w, h)), nil
+}
+
+// newBand cria uma faixa branca da largura da imagem
+func newBand(w, h int) *image.Gray {
+	band := image.NewGray(image.Rect(0, 0, w, h))
+	draw.Draw(band, band.Bounds(), image.White, image.Point{}, draw.Src)
+	return band
+}
+
+// doubleHeight duplica as linhas da faixa, como o modo de altura dupla das
+// impressoras térmicas
+func doubleHeight(band *image.Gray) *image.Gray {
+	b := band.Bounds()
+	out := image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()*2))
+	for y := 0; y < b.Dy()*2; y++ {
+		copy(out.Pix[y*out.Stride:y*out.Stride+b.Dx()], band.Pix[(y/2)*band.Stride:(y/2)*band.Stride+b.Dx()])
+	}
+	return out
+}
+
+// monoFaceSet contém as variações da fonte monoespaçada em um tamanho
+type monoFaceSet struct {
+	regular font.Face
+	bold    font.Face
+}
+
+var (
+	monoFontsOnce sync.Once
+	monoRegular   *opentype.Font
+	monoBold      *opentype.Font
+	monoFontsErr  error
+)
+
+// monoFaces retorna a fonte Go Mono com a largura de caractere cell, em pontos
+func monoFaces(cell int) (monoFaceSet, error) {
+	monoFontsOnce.Do(func() {
+		if monoRegular, monoFontsErr = opentype.Parse(gomono.TTF); monoFontsErr != nil {
+			return
+		}
+		monoBold, monoFontsErr = opentype.Parse(gomonobold.TTF)
+	})
+	if monoFontsErr != nil {
+		return monoFaceSet{}, fmt.Errorf("erro ao carregar fonte: %w", monoFontsErr)
+	}
+
+	// O avanço dos caracteres da Go Mono é de 0,6 em
+	opts := &opentype.FaceOptions{Size: float64(cell) / 0.6, DPI: 72, Hinting: font.HintingFull}
+	regular, err := opentype.NewFace(monoRegular, opts)
+	if err != nil {
+		return monoFaceSet{}, fmt.Errorf("erro ao carregar fonte: %w", err)
+	}
+	bold, err := opentype.NewFace(monoBold, opts)
+	if err != nil {
+		return monoFaceSet{}, fmt.Errorf("erro ao carregar fonte: %w", err)
+	}
+	return monoFaceSet{regular: regular, bold: bold}, nil
+}
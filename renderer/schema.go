@@ -0,0 +1,134 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// JSONSchemaID é o identificador do JSON Schema publicado com a biblioteca
+const JSONSchemaID = "https://github.com/marcelo-cunha/nfce-render/schema/nfce.schema.json"
+
+// JSONSchema gera o JSON Schema (draft 2020-12) da saída de JSONRenderer
+// a partir dos tipos Go. O resultado é publicado em schema/nfce.schema.json.
+func JSONSchema() ([]byte, error) {
+	defs := jsonObject{}
+	root := schemaStruct(reflect.TypeOf(jsonDocument{}), &defs)
+
+	schema := jsonObject{
+		{"$schema", "https://json-schema.org/draft/2020-12/schema"},
+		{"$id", JSONSchemaID},
+		{"title", "NFC-e"},
+		{"description", "NFC-e serializada pelo nfce-render (schema_version " + JSONSchemaVersion + ")"},
+	}
+	schema = append(schema, root...)
+	schema = append(schema, jsonField{"$defs", defs})
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(schema); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// schemaStruct descreve uma struct como objeto JSON Schema, registrando
+// em defs os tipos aninhados
+func schemaStruct(t reflect.Type, defs *jsonObject) jsonObject {
+	properties := jsonObject{}
+	required := []string{}
+
+	for _, f := range jsonFields(t) {
+		ft := t.Field(f.index).Type
+		properties = append(properties, jsonField{f.name, schemaType(ft, !f.omitempty, defs)})
+		if !f.omitempty {
+			required = append(required, f.name)
+		}
+
+		if helper, ok := jsonHelpers[f.xmlName]; ok && ft.Kind() == reflect.String {
+			properties = append(properties, jsonField{f.name + helper.suffix, jsonObject{
+				{"type", "string"},
+				{"description", helper.description},
+			}})
+			if !f.omitempty {
+				required = append(required, f.name+helper.suffix)
+			}
+		}
+		if isTimeType(ft) {
+			properties = append(properties, jsonField{f.name + "_formatada", jsonObject{
+				{"type", "string"},
+				{"description", "Data no formato dd/mm/aaaa hh:mm:ss"},
+			}})
+		}
+	}
+
+	return jsonObject{
+		{"type", "object"},
+		{"properties", properties},
+		{"required", required},
+		{"additionalProperties", false},
+	}
+}
+
+// schemaType descreve um tipo Go em JSON Schema. Ponteiros obrigatórios
+// aceitam null.
+func schemaType(t reflect.Type, nullable bool, defs *jsonObject) jsonObject {
+	if t.Kind() == reflect.Pointer {
+		elem := schemaType(t.Elem(), false, defs)
+		if !nullable {
+			return elem
+		}
+		return jsonObject{{"anyOf", []any{elem, jsonObject{{"type", "null"}}}}}
+	}
+
+	if t == timeType {
+		// Datas não informadas são serializadas como null
+		return jsonObject{{"type", []string{"string", "null"}}, {"format", "date-time"}}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		name := strings.TrimPrefix(t.Name(), "json")
+		if !defs.has(name) {
+			// Registra antes de descrever para suportar tipos recursivos
+			*defs = append(*defs, jsonField{name, nil})
+			def := schemaStruct(t, defs)
+			defs.set(name, def)
+		}
+		return jsonObject{{"$ref", "#/$defs/" + name}}
+	case reflect.Slice:
+		return jsonObject{{"type", "array"}, {"items", schemaType(t.Elem(), false, defs)}}
+	case reflect.Bool:
+		return jsonObject{{"type", "boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return jsonObject{{"type", "integer"}}
+	case reflect.Float32, reflect.Float64:
+		return jsonObject{{"type", "number"}}
+	default:
+		return jsonObject{{"type", "string"}}
+	}
+}
+
+// has verifica se o objeto contém a chave
+func (o jsonObject) has(key string) bool {
+	for _, f := range o {
+		if f.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// set substitui o valor da chave
+func (o jsonObject) set(key string, value any) {
+	for i := range o {
+		if o[i].key == key {
+			o[i].value = value
+			return
+		}
+	}
+}
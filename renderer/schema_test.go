@@ -0,0 +1,23 @@
+package renderer
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// TestJSONSchemaUpToDate falha quando schema/nfce.schema.json não
+// corresponde aos tipos Go. Para atualizar: go generate ./renderer
+func TestJSONSchemaUpToDate(t *testing.T) {
+	want, err := JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("../schema/nfce.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("schema/nfce.schema.json desatualizado; execute go generate ./renderer")
+	}
+}
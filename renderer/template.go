@@ -0,0 +1,210 @@
+package renderer
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marcelo-cunha/nfce-render/xmlparser"
+)
+
+// Blocos do template padrão que podem ser redefinidos com {{define}}
+const (
+	BlockStyles   = "styles"
+	BlockHeader   = "header"
+	BlockItems    = "items"
+	BlockTotals   = "totals"
+	BlockPayment  = "payment"
+	BlockConsumer = "consumer"
+	BlockFooter   = "footer"
+)
+
+// Template é um template do DANFE já interpretado. Pode ser compartilhado
+// entre renderizadores e goroutines: o parse é feito uma única vez.
+type Template struct {
+	tmpl *template.Template
+}
+
+var (
+	defaultTemplateOnce sync.Once
+	defaultTemplate     *Template
+	defaultTemplateErr  error
+	// baseTemplate nunca é executado: o html/template não permite copiar um
+	// template depois da primeira execução, e ParseTemplate parte dele
+	baseTemplate *template.Template
+)
+
+// DefaultTemplate retorna o template padrão do DANFE
+func DefaultTemplate() (*Template, error) {
+	defaultTemplateOnce.Do(func() {
+		base, err := template.New("danfe").Funcs(templateFuncs).Parse(danfeTemplate)
+		if err != nil {
+			defaultTemplateErr = fmt.Errorf("erro ao fazer parse do template: %w", err)
+			return
+		}
+		tmpl, err := base.Clone()
+		if err != nil {
+			defaultTemplateErr = fmt.Errorf("erro ao copiar template padrão: %w", err)
+			return
+		}
+		baseTemplate = base
+		defaultTemplate = &Template{tmpl: tmpl}
+	})
+	return defaultTemplate, defaultTemplateErr
+}
+
+// ParseTemplate interpreta um template sobre o template padrão. O texto pode
+// redefinir os blocos (header, items, totals, payment, consumer, footer e
+// styles) com {{define "items"}}...{{end}}, ou o layout inteiro com
+// {{define "danfe"}}. As funções do template padrão (formatCNPJ,
+// formatCurrency, formatKey...) e os métodos dos dados de cada via
+// ($.FormatDate, $.ItemDescription, $.ConsumerName, $.LogoStyle,
+// $.QRCode...) estão disponíveis.
+//
+// O template é validado com uma renderização de teste sobre dados de exemplo.
+func ParseTemplate(text string) (*Template, error) {
+	return extendTemplate(func(tmpl *template.Template) (*template.Template, error) {
+		return tmpl.New("custom").Parse(text)
+	})
+}
+
+// ParseTemplateFS interpreta os arquivos de fsys que correspondem aos padrões
+// (por exemplo "templates/*.html") sobre o template padrão, como ParseTemplate
+func ParseTemplateFS(fsys fs.FS, patterns ...string) (*Template, error) {
+	return extendTemplate(func(tmpl *template.Template) (*template.Template, error) {
+		return tmpl.ParseFS(fsys, patterns...)
+	})
+}
+
+// extendTemplate aplica o parse sobre uma cópia do template padrão e faz a
+// renderização de teste
+func extendTemplate(parse func(*template.Template) (*template.Template, error)) (*Template, error) {
+	if _, err := DefaultTemplate(); err != nil {
+		return nil, err
+	}
+	tmpl, err := baseTemplate.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao copiar template padrão: %w", err)
+	}
+	if _, err := parse(tmpl); err != nil {
+		return nil, fmt.Errorf("erro ao fazer parse do template: %w", err)
+	}
+
+	t := &Template{tmpl: tmpl}
+	sample := newConfig(sampleNFe(), []Option{WithVias(ViaConsumidor, ViaEstabelecimento)})
+	if err := t.execute(io.Discard, &sample, true); err != nil {
+		return nil, fmt.Errorf("erro na renderização de teste do template: %w", err)
+	}
+	return t, nil
+}
+
+// execute renderiza o template com os dados da configuração c. O que
+// depende da configuração chega ao template pelos dados, então o mesmo
+// template é executado por todos os renderizadores.
+func (t *Template) execute(w io.Writer, c *config, sample bool) error {
+	data := pageDataFor(c)
+	if sample {
+		// Exercita os blocos condicionais do template
+		for i := range data.Copias {
+			data.Copias[i].Cancelamento = sampleCancelamento()
+			data.Copias[i].StatusBanner = "DENEGADA"
+			data.Copias[i].Homologacao = true
+			data.Copias[i].PendenteAutorizacao = true
+		}
+	}
+	return t.tmpl.ExecuteTemplate(w, "danfe", data)
+}
+
+// templateFuncs são as funções disponíveis nos templates. Não dependem da
+// configuração do renderizador, que chega pelos métodos de danfeData:
+// formatDate e formatDateOnly usam o fuso da própria data e generateQRCode
+// as opções e o tamanho padrão, enquanto $.FormatDate, $.FormatDateOnly e
+// $.QRCode seguem as opções do renderizador.
+var templateFuncs = template.FuncMap{
+	"formatCNPJ":     xmlparser.FormatCNPJ,
+	"formatCPF":      xmlparser.FormatCPF,
+	"formatCEP":      xmlparser.FormatCEP,
+	"formatCurrency": xmlparser.FormatCurrency,
+	"formatQuantity": xmlparser.FormatQuantity,
+	"formatDate": func(t time.Time) string {
+		return t.Format("02/01/2006 15:04:05")
+	},
+	"formatDateOnly": func(t time.Time) string {
+		return t.Format("02/01/2006")
+	},
+	"getPaymentMethod": xmlparser.GetPaymentMethodDescription,
+	"generateQRCode": func(content string) template.HTML {
+		return generateQRCodeHTML(content, QRCodeOptions{}, Paper80mm.QRCodeMM)
+	},
+	"upper": strings.ToUpper,
+	"add": func(a, b int) int {
+		return a + b
+	},
+	"formatKey": xmlparser.FormatChaveAcesso,
+}
+
+// sampleNFe retorna a NFC-e de exemplo usada na validação de templates
+func sampleNFe() *xmlparser.NFeProc {
+	dhEmi := time.Date(2024, time.January, 15, 10, 30, 0, 0, xmlparser.Brasilia)
+	return &xmlparser.NFeProc{
+		Versao: "4.00",
+		NFe: xmlparser.NFe{
+			InfNFe: xmlparser.InfNFe{
+				ID:     "NFe35240111222333000181650010000000011000000014",
+				Versao: "4.00",
+				Ide: xmlparser.Ide{
+					CUF: "35", CNF: "00000001", NatOp: "VENDA", Mod: "65", Serie: "1", NNF: "1",
+					DHEmi: dhEmi, TpEmis: "9", CDV: "4", TpAmb: "2", DHCont: &dhEmi,
+					XJust: "Falha de comunicacao com a SEFAZ",
+				},
+				Emit: xmlparser.Emit{
+					CNPJ: "11222333000181", XNome: "EMPRESA DE EXEMPLO LTDA", XFant: "EXEMPLO",
+					EnderEmit: xmlparser.EnderEmit{
+						XLgr: "RUA DE EXEMPLO", Nro: "100", XBairro: "CENTRO", CMun: "3550308",
+						XMun: "SAO PAULO", UF: "SP", CEP: "01000000",
+					},
+					IE: "111222333444", CRT: "1",
+				},
+				Dest: &xmlparser.Dest{CPF: "12345678909", XNome: "CONSUMIDOR DE EXEMPLO"},
+				Det: []xmlparser.Det{{
+					NItem: "1",
+					Prod: xmlparser.Prod{
+						CProd: "001", XProd: "PRODUTO DE EXEMPLO", UCom: "UN",
+						QCom: 2, VUnCom: 10, VProd: 20, VDesc: 1,
+					},
+				}},
+				Total: xmlparser.Total{ICMSTot: xmlparser.ICMSTot{VProd: 20, VDesc: 1, VOutro: 1, VNF: 20}},
+				Pag: xmlparser.Pag{
+					DetPag: []xmlparser.DetPag{{TPag: "01", VPag: 50}},
+					VTroco: 30,
+				},
+				InfAdic: &xmlparser.InfAdic{InfCpl: "Informacoes complementares"},
+			},
+			InfNFeSupl: &xmlparser.InfNFeSupl{
+				QrCode:   "https://www.sefaz.sp.gov.br/nfce/qrcode?p=35240111222333000181650010000000011000000014|2|2|1|0",
+				UrlChave: "www.sefaz.sp.gov.br/nfce/consulta",
+			},
+		},
+		ProtNFe: xmlparser.ProtNFe{
+			InfProt: xmlparser.InfProt{
+				TpAmb: "2", ChNFe: "35240111222333000181650010000000011000000014", DhRecbto: dhEmi,
+				NProt: "135240000000001", CStat: "110", XMotivo: "Uso Denegado",
+			},
+		},
+	}
+}
+
+// sampleCancelamento retorna o evento de cancelamento de exemplo
+func sampleCancelamento() *xmlparser.ProcEventoNFe {
+	return &xmlparser.ProcEventoNFe{
+		Evento: xmlparser.Evento{InfEvento: xmlparser.InfEvento{
+			TpEvento:  xmlparser.TpEventoCancelamento,
+			DetEvento: xmlparser.DetEvento{NProt: "135240000000002", XJust: "Cancelamento de exemplo"},
+		}},
+		RetEvento: xmlparser.RetEvento{InfEvento: xmlparser.InfRetEvento{CStat: "135", NProt: "135240000000002"}},
+	}
+}
@@ -0,0 +1,94 @@
+package renderer
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTemplateSharedAcrossRenderers(t *testing.T) {
+	tmpl, err := ParseTemplate(`{{define "footer"}}<p class="emissao">{{.FormatDate .NFe.NFe.InfNFe.Ide.DHEmi}}</p>{{end}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zones := map[string]*time.Location{
+		"15/01/2024 10:30:00": time.FixedZone("-03", -3*60*60),
+		"15/01/2024 09:30:00": time.FixedZone("-04", -4*60*60),
+		"15/01/2024 08:30:00": time.FixedZone("-05", -5*60*60),
+	}
+
+	var wg sync.WaitGroup
+	for want, loc := range zones {
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(want string, loc *time.Location) {
+				defer wg.Done()
+				var buf bytes.Buffer
+				r := NewHTMLRenderer(sampleNFe(), WithTemplate(tmpl), WithLocation(loc))
+				if err := r.RenderToWriter(&buf); err != nil {
+					t.Error(err)
+					return
+				}
+				if !strings.Contains(buf.String(), `<p class="emissao">`+want+`</p>`) {
+					t.Errorf("fuso %s: data %s não encontrada", loc, want)
+				}
+			}(want, loc)
+		}
+	}
+	wg.Wait()
+}
+
+func TestParseTemplateAfterDefaultRender(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewHTMLRenderer(sampleNFe()).RenderToWriter(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseTemplate(`{{define "consumer"}}{{.ConsumerName}}{{end}}`); err != nil {
+		t.Fatalf("ParseTemplate depois de renderizar o template padrão: %v", err)
+	}
+}
+
+func TestEmailRendererDates(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewEmailRenderer(sampleNFe(), WithLocation(time.FixedZone("-04", -4*60*60)), WithCancelamento(sampleCancelamento()))
+	if err := r.RenderToWriter(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "15/01/2024 09:30:00") {
+		t.Errorf("data de emissão no fuso configurado não encontrada:\n%s", buf.String())
+	}
+}
+
+func TestParseTemplateFuncs(t *testing.T) {
+	tmpl, err := ParseTemplate(`{{define "footer"}}
+<p class="func">{{formatDate .NFe.NFe.InfNFe.Ide.DHEmi}} {{formatDateOnly .NFe.NFe.InfNFe.Ide.DHEmi}}</p>
+<p class="method">{{$.FormatDate .NFe.NFe.InfNFe.Ide.DHEmi}} {{$.FormatDateOnly .NFe.NFe.InfNFe.Ide.DHEmi}}</p>
+<div class="func-qr">{{generateQRCode .NFe.GetQRCode}}</div>
+<div class="method-qr">{{$.QRCode .NFe.GetQRCode}}</div>
+{{end}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	r := NewHTMLRenderer(sampleNFe(), WithTemplate(tmpl), WithLocation(time.FixedZone("-04", -4*60*60)), WithQRCode(QRCodeOptions{PNG: true}))
+	if err := r.RenderToWriter(&buf); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+
+	// As funções usam o fuso da data; os métodos, o do renderizador
+	for _, want := range []string{
+		`<p class="func">15/01/2024 10:30:00 15/01/2024</p>`,
+		`<p class="method">15/01/2024 09:30:00 15/01/2024</p>`,
+		`<div class="func-qr"><svg`,
+		`<div class="method-qr"><img src="data:image/png;base64,`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("%s não encontrado", want)
+		}
+	}
+}
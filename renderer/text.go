@@ -0,0 +1,357 @@
+package renderer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/marcelo-cunha/nfce-render/xmlparser"
+)
+
+// TextQRCodeMarker inicia a linha que contém o conteúdo do QR Code na saída
+// em texto. O driver da impressora pode substituir a linha pelo QR Code.
+const TextQRCodeMarker = "@@QRCODE@@ "
+
+// Larguras de linha mais comuns das impressoras térmicas
+const (
+	Columns32 = 32 // bobina de 58mm
+	Columns42 = 42 // bobina de 80mm com fonte B ou impressoras Bematech
+	Columns48 = 48 // bobina de 80mm com fonte A
+)
+
+// TextRenderer é responsável pela renderização do DANFE em texto monoespaçado
+type TextRenderer struct {
+	config
+}
+
+// NewTextRenderer cria uma nova instância do renderizador de texto
+func NewTextRenderer(nfe *xmlparser.NFeProc, opts ...Option) *TextRenderer {
+	return &TextRenderer{
+		config: newConfig(nfe, opts),
+	}
+}
+
+// RenderToWriter renderiza o DANFE em texto para um io.Writer
+func (r *TextRenderer) RenderToWriter(writer io.Writer) error {
+	columns := r.textColumns()
+
+	var b strings.Builder
+	for _, line := range r.receipt(columns) {
+		switch line.kind {
+		case lineQRCode:
+			b.WriteString(TextQRCodeMarker + line.text)
+		case lineCut:
+			b.WriteString(strings.Repeat("- ", columns/2))
+		case lineLogo:
+			continue
+		default:
+			b.WriteString(strings.TrimRight(alignText(line.text, line.align, columns), " "))
+		}
+		b.WriteByte('\n')
+	}
+
+	_, err := io.WriteString(writer, b.String())
+	return err
+}
+
+// lineKind identifica o tipo de uma linha do cupom
+type lineKind int
+
+const (
+	lineText lineKind = iota
+	lineQRCode
+	lineLogo
+	lineCut
+)
+
+// lineAlign é o alinhamento de uma linha do cupom, com os valores do
+// comando ESC a
+type lineAlign int
+
+const (
+	alignLeft lineAlign = iota
+	alignCenter
+	alignRight
+)
+
+// receiptLine é uma linha do DANFE em texto, com os atributos usados pela
+// saída ESC/POS
+type receiptLine struct {
+	kind  lineKind
+	text  string
+	align lineAlign
+	bold  bool
+	large bool // altura dupla
+}
+
+// receiptBuilder monta as linhas do cupom respeitando o número de colunas
+type receiptBuilder struct {
+	columns int
+	lines   []receiptLine
+}
+
+// add acrescenta uma linha, quebrando o texto que não cabe na largura
+func (b *receiptBuilder) add(text string, align lineAlign, bold bool) {
+	for _, part := range wrapText(text, b.columns) {
+		b.lines = append(b.lines, receiptLine{text: part, align: align, bold: bold})
+	}
+}
+
+// title acrescenta um título centralizado, em negrito e altura dupla
+func (b *receiptBuilder) title(text string) {
+	for _, part := range wrapText(text, b.columns) {
+		b.lines = append(b.lines, receiptLine{text: part, align: alignCenter, bold: true, large: true})
+	}
+}
+
+// pair acrescenta um rótulo à esquerda e um valor à direita
+func (b *receiptBuilder) pair(label, value string, bold bool) {
+	b.lines = append(b.lines, receiptLine{text: joinColumns(label, value, b.columns), bold: bold})
+}
+
+// separator acrescenta uma linha tracejada
+func (b *receiptBuilder) separator() {
+	b.lines = append(b.lines, receiptLine{text: strings.Repeat("-", b.columns)})
+}
+
+// section acrescenta um título de seção entre separadores
+func (b *receiptBuilder) section(text string) {
+	b.separator()
+	b.add(text, alignCenter, true)
+	b.separator()
+}
+
+// receipt monta as linhas do DANFE NFC-e para cada via
+func (c *config) receipt(columns int) []receiptLine {
+	b := &receiptBuilder{columns: columns}
+
+	vias := c.vias
+	if len(vias) == 0 {
+		vias = []string{""}
+	}
+	for i, via := range vias {
+		if i > 0 {
+			b.lines = append(b.lines, receiptLine{kind: lineCut})
+		}
+		c.receiptVia(b, via)
+	}
+	return b.lines
+}
+
+// receiptVia monta as linhas de uma via do DANFE
+func (c *config) receiptVia(b *receiptBuilder, via string) {
+	inf := &c.nfe.NFe.InfNFe
+	emit := &inf.Emit
+
+	if c.logo != nil {
+		b.lines = append(b.lines, receiptLine{kind: lineLogo, align: alignCenter})
+	}
+
+	// Identificação do emitente
+	b.add(emit.XNome, alignCenter, true)
+	cnpj := "CNPJ: " + xmlparser.FormatCNPJ(emit.CNPJ)
+	if emit.IE != "" {
+		cnpj += " IE: " + emit.IE
+	}
+	b.add(cnpj, alignCenter, false)
+	b.add(fmt.Sprintf("%s, %s, %s", emit.EnderEmit.XLgr, emit.EnderEmit.Nro, emit.EnderEmit.XBairro), alignCenter, false)
+	b.add(fmt.Sprintf("%s-%s CEP: %s", emit.EnderEmit.XMun, emit.EnderEmit.UF, xmlparser.FormatCEP(emit.EnderEmit.CEP)), alignCenter, false)
+	b.separator()
+	b.add("DANFE NFC-e - Documento Auxiliar da Nota Fiscal de Consumidor Eletrônica", alignCenter, true)
+	if via != "" {
+		b.add(strings.ToUpper(via), alignCenter, true)
+	}
+
+	// Avisos de situação
+	if c.isHomologacao() {
+		b.separator()
+		b.add(xmlparser.MensagemHomologacao, alignCenter, true)
+	}
+	if c.nfe.IsContingenciaOffline() {
+		b.separator()
+		b.title("EMITIDA EM CONTINGÊNCIA")
+		if c.pendente {
+			b.add("Pendente de autorização", alignCenter, false)
+		}
+		if inf.Ide.DHCont != nil {
+			b.add("Entrada em contingência: "+c.formatDate(*inf.Ide.DHCont), alignCenter, false)
+		}
+		if inf.Ide.XJust != "" {
+			b.add("Justificativa: "+inf.Ide.XJust, alignCenter, false)
+		}
+	}
+	if c.statusBanner != "" {
+		b.separator()
+		b.title(c.statusBanner)
+		if prot := c.nfe.ProtNFe.InfProt; prot.CStat != "" {
+			b.add(prot.CStat+" - "+prot.XMotivo, alignCenter, false)
+		}
+	}
+	if c.cancelamento != nil {
+		b.separator()
+		b.title("NFC-e CANCELADA")
+		b.add("Protocolo de cancelamento: "+c.cancelamento.GetProtocolo(), alignCenter, false)
+		b.add("Data: "+c.formatDate(c.cancelamento.GetDataRegistro()), alignCenter, false)
+		b.add("Justificativa: "+c.cancelamento.GetJustificativa(), alignCenter, false)
+	}
+
+	// Itens
+	b.section("ITENS")
+	b.add("# CÓDIGO DESCRIÇÃO", alignLeft, true)
+	b.add("QTD UN x VL UNIT = VL TOTAL", alignRight, true)
+	for i, det := range inf.Det {
+		b.add(fmt.Sprintf("%03d %s %s", i+1, det.Prod.CProd, c.itemDescription(i, det)), alignLeft, false)
+		b.add(fmt.Sprintf("%s %s x %s = %s",
+			xmlparser.FormatQuantity(det.Prod.QCom), det.Prod.UCom,
+			formatAmount(det.Prod.VUnCom), formatAmount(det.Prod.VProd)), alignRight, false)
+		if det.Prod.VDesc > 0 {
+			b.pair("  Desconto", "-"+formatAmount(det.Prod.VDesc), false)
+		}
+	}
+
+	// Totais
+	tot := inf.Total.ICMSTot
+	b.separator()
+	b.pair("Qtde. total de itens", fmt.Sprint(len(inf.Det)), false)
+	b.pair("Valor total R$", formatAmount(tot.VProd), false)
+	if tot.VDesc > 0 {
+		b.pair("Desconto R$", formatAmount(tot.VDesc), false)
+	}
+	if tot.VOutro > 0 {
+		b.pair("Outros valores R$", formatAmount(tot.VOutro), false)
+	}
+	b.pair("Valor a pagar R$", formatAmount(tot.VNF), true)
+
+	// Pagamento
+	b.pair("FORMA DE PAGAMENTO", "VALOR PAGO R$", true)
+	for _, p := range inf.Pag.DetPag {
+		b.pair(xmlparser.GetPaymentMethodDescription(p.TPag), formatAmount(p.VPag), false)
+	}
+	if inf.Pag.VTroco > 0 {
+		b.pair("Troco R$", formatAmount(inf.Pag.VTroco), false)
+	}
+
+	// Consulta pela chave de acesso
+	b.separator()
+	if supl := c.nfe.NFe.InfNFeSupl; supl != nil && supl.UrlChave != "" {
+		b.add("Consulte pela Chave de Acesso em", alignCenter, true)
+		b.add(supl.UrlChave, alignCenter, false)
+	}
+	b.add(xmlparser.FormatChaveAcesso(c.nfe.GetChaveAcesso()), alignCenter, false)
+
+	// Consumidor
+	b.separator()
+	if dest := inf.Dest; dest != nil {
+		switch {
+		case dest.CPF != "":
+			b.add("CONSUMIDOR - CPF "+xmlparser.FormatCPF(dest.CPF), alignCenter, true)
+		case dest.CNPJ != "":
+			b.add("CONSUMIDOR - CNPJ "+xmlparser.FormatCNPJ(dest.CNPJ), alignCenter, true)
+		default:
+			b.add("CONSUMIDOR", alignCenter, true)
+		}
+		if name := c.consumerName(); name != "" {
+			b.add(name, alignCenter, false)
+		}
+	} else {
+		b.add("CONSUMIDOR NÃO IDENTIFICADO", alignCenter, true)
+	}
+
+	// Identificação da NFC-e e protocolo
+	b.separator()
+	b.add(fmt.Sprintf("NFC-e nº %s Série %s %s", inf.Ide.NNF, inf.Ide.Serie, c.formatDate(inf.Ide.DHEmi)), alignCenter, true)
+	if prot := c.nfe.ProtNFe.InfProt; prot.NProt != "" {
+		b.add("Protocolo de autorização: "+prot.NProt, alignCenter, false)
+		b.add("Data de autorização: "+c.formatDate(prot.DhRecbto), alignCenter, false)
+	}
+
+	// QR Code
+	if qr := c.nfe.GetQRCode(); qr != "" {
+		b.lines = append(b.lines, receiptLine{kind: lineQRCode, text: qr, align: alignCenter})
+	}
+
+	// Informações complementares
+	if adic := inf.InfAdic; adic != nil && adic.InfCpl != "" {
+		b.separator()
+		b.add("Informações de interesse do contribuinte:", alignLeft, true)
+		b.add(adic.InfCpl, alignLeft, false)
+	}
+}
+
+// textColumns retorna o número de colunas configurado ou o padrão do papel
+func (c *config) textColumns() int {
+	if c.columns > 0 {
+		return c.columns
+	}
+	if c.paper.ContentWidthMM > 0 && c.paper.ContentWidthMM < 70 {
+		return Columns32
+	}
+	return Columns48
+}
+
+// formatAmount formata valores monetários sem o símbolo da moeda
+func formatAmount(value float64) string {
+	return strings.TrimPrefix(xmlparser.FormatCurrency(value), "R$ ")
+}
+
+// alignText posiciona o texto na largura informada
+func alignText(text string, align lineAlign, columns int) string {
+	pad := columns - utf8.RuneCountInString(text)
+	if pad <= 0 {
+		return text
+	}
+	switch align {
+	case alignCenter:
+		return strings.Repeat(" ", pad/2) + text + strings.Repeat(" ", pad-pad/2)
+	case alignRight:
+		return strings.Repeat(" ", pad) + text
+	default:
+		return text + strings.Repeat(" ", pad)
+	}
+}
+
+// joinColumns alinha label à esquerda e value à direita na mesma linha,
+// truncando o rótulo quando necessário
+func joinColumns(label, value string, columns int) string {
+	space := columns - utf8.RuneCountInString(value) - 1
+	if space < 1 {
+		return value
+	}
+	runes := []rune(label)
+	if len(runes) > space {
+		runes = runes[:space]
+	}
+	return string(runes) + strings.Repeat(" ", columns-len(runes)-utf8.RuneCountInString(value)) + value
+}
+
+// wrapText quebra o texto em linhas de até columns caracteres, preferindo
+// quebrar entre palavras
+func wrapText(text string, columns int) []string {
+	var lines []string
+	var current []rune
+	for _, word := range strings.Fields(text) {
+		w := []rune(word)
+		for len(w) > columns {
+			if len(current) > 0 {
+				lines = append(lines, string(current))
+				current = nil
+			}
+			lines = append(lines, string(w[:columns]))
+			w = w[columns:]
+		}
+		switch {
+		case len(current) == 0:
+			current = w
+		case len(current)+1+len(w) <= columns:
+			current = append(append(current, ' '), w...)
+		default:
+			lines = append(lines, string(current))
+			current = w
+		}
+	}
+	if len(current) > 0 || len(lines) == 0 {
+		lines = append(lines, string(current))
+	}
+	return lines
+}
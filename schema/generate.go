@@ -0,0 +1,26 @@
+//go:build ignore
+
+// generate escreve o JSON Schema da saída FormatJSON. Executado por
+// go generate ./renderer.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/marcelo-cunha/nfce-render/renderer"
+)
+
+func main() {
+	output := flag.String("o", "nfce.schema.json", "arquivo de saída")
+	flag.Parse()
+
+	schema, err := renderer.JSONSchema()
+	if err != nil {
+		log.Fatalf("erro ao gerar JSON Schema: %v", err)
+	}
+	if err := os.WriteFile(*output, schema, 0644); err != nil {
+		log.Fatalf("erro ao salvar JSON Schema: %v", err)
+	}
+}
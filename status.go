@@ -0,0 +1,41 @@
+package nfce
+
+import "github.com/marcelo-cunha/nfce-render/xmlparser"
+
+// GetStatus retorna a situação da NF-e de acordo com o protocolo da SEFAZ
+func (g *Generator) GetStatus() xmlparser.AuthorizationStatus {
+	return g.nfe.GetStatus()
+}
+
+// IsPendenteAutorizacao verifica se a NFC-e foi emitida em contingência offline
+// e ainda não possui protocolo de autorização
+func (g *Generator) IsPendenteAutorizacao() bool {
+	return g.nfe.IsContingenciaOffline() && g.GetStatus() == xmlparser.StatusMissing
+}
+
+// checkStatus aplica a política de situação antes da geração do DANFE.
+// NFC-e em contingência offline podem ser impressas antes da autorização.
+func (g *Generator) checkStatus() error {
+	if g.statusPolicy != StatusPolicyRefuse || g.GetStatus() == xmlparser.StatusAuthorized || g.IsPendenteAutorizacao() {
+		return nil
+	}
+	return &NotAuthorizedError{
+		CStat:   g.nfe.ProtNFe.InfProt.CStat,
+		XMotivo: g.nfe.ProtNFe.InfProt.XMotivo,
+	}
+}
+
+// statusBanner retorna o texto da faixa de situação exibida no DANFE
+func (g *Generator) statusBanner() string {
+	if g.statusPolicy != StatusPolicyBanner || g.IsPendenteAutorizacao() {
+		return ""
+	}
+	switch g.GetStatus() {
+	case xmlparser.StatusAuthorized:
+		return ""
+	case xmlparser.StatusDenied:
+		return "DENEGADA"
+	default:
+		return "NÃO AUTORIZADA"
+	}
+}
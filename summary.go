@@ -0,0 +1,130 @@
+package nfce
+
+import (
+	"time"
+
+	"github.com/marcelo-cunha/nfce-render/xmlparser"
+)
+
+// SummaryStatus é a situação da NF-e exibida no resumo
+type SummaryStatus string
+
+const (
+	SummaryAuthorized  SummaryStatus = "autorizada"
+	SummaryCancelled   SummaryStatus = "cancelada"
+	SummaryContingency SummaryStatus = "contingencia" // emitida offline, pendente de autorização
+	SummaryDenied      SummaryStatus = "denegada"
+	SummaryRejected    SummaryStatus = "rejeitada"
+	SummaryMissing     SummaryStatus = "sem protocolo"
+)
+
+// Summary é um resumo da NFC-e pronto para exibição em listas e buscas.
+// Documentos e datas já vêm formatados.
+type Summary struct {
+	Chave                string
+	ChaveFormatada       string
+	Numero               string
+	Serie                string
+	DataEmissao          time.Time // no fuso definido por WithTimezone
+	DataEmissaoFormatada string    // dd/mm/aaaa hh:mm:ss
+
+	NomeEmitente string
+	NomeFantasia string
+	CNPJEmitente string // com máscara
+	UFEmitente   string
+
+	Status       SummaryStatus
+	Homologacao  bool
+	Contingencia bool // emitida em contingência offline (tpEmis 9)
+	Protocolo    string
+
+	QuantidadeItens int
+	Desconto        float64
+	Total           float64
+	Troco           float64
+	Pagamentos      []SummaryPayment
+
+	NomeConsumidor      string
+	DocumentoConsumidor string // CPF ou CNPJ com máscara, vazio se não identificado
+
+	URLConsulta string
+}
+
+// SummaryPayment é uma forma de pagamento do resumo
+type SummaryPayment struct {
+	Codigo    string // tPag
+	Descricao string
+	Valor     float64
+}
+
+// Summary retorna o resumo da NFC-e
+func (g *Generator) Summary() Summary {
+	inf := &g.nfe.NFe.InfNFe
+	chave := g.nfe.GetChaveAcesso()
+	dataEmissao := g.nfe.GetDataEmissao().In(g.location())
+
+	s := Summary{
+		Chave:                chave,
+		ChaveFormatada:       xmlparser.FormatChaveAcesso(chave),
+		Numero:               g.nfe.GetNumeroNF(),
+		Serie:                g.nfe.GetSerieNF(),
+		DataEmissao:          dataEmissao,
+		DataEmissaoFormatada: dataEmissao.Format("02/01/2006 15:04:05"),
+		NomeEmitente:         inf.Emit.XNome,
+		NomeFantasia:         inf.Emit.XFant,
+		CNPJEmitente:         xmlparser.FormatCNPJ(inf.Emit.CNPJ),
+		UFEmitente:           g.nfe.GetUF(),
+		Status:               g.summaryStatus(),
+		Homologacao:          g.IsHomologacao(),
+		Contingencia:         g.nfe.IsContingenciaOffline(),
+		Protocolo:            g.nfe.ProtNFe.InfProt.NProt,
+		QuantidadeItens:      len(inf.Det),
+		Desconto:             inf.Total.ICMSTot.VDesc,
+		Total:                g.nfe.GetValorTotal(),
+		Troco:                inf.Pag.VTroco,
+	}
+
+	for _, p := range inf.Pag.DetPag {
+		descricao := p.XPag
+		if descricao == "" {
+			descricao = xmlparser.GetPaymentMethodDescription(p.TPag)
+		}
+		s.Pagamentos = append(s.Pagamentos, SummaryPayment{Codigo: p.TPag, Descricao: descricao, Valor: p.VPag})
+	}
+
+	if dest := inf.Dest; dest != nil {
+		s.NomeConsumidor = dest.XNome
+		switch {
+		case dest.CPF != "":
+			s.DocumentoConsumidor = xmlparser.FormatCPF(dest.CPF)
+		case dest.CNPJ != "":
+			s.DocumentoConsumidor = xmlparser.FormatCNPJ(dest.CNPJ)
+		}
+	}
+
+	if supl := g.nfe.NFe.InfNFeSupl; supl != nil {
+		s.URLConsulta = supl.UrlChave
+	}
+
+	return s
+}
+
+// summaryStatus resume a situação considerando eventos e contingência
+func (g *Generator) summaryStatus() SummaryStatus {
+	if g.IsCancelada() {
+		return SummaryCancelled
+	}
+	if g.IsPendenteAutorizacao() {
+		return SummaryContingency
+	}
+	switch g.GetStatus() {
+	case xmlparser.StatusAuthorized:
+		return SummaryAuthorized
+	case xmlparser.StatusDenied:
+		return SummaryDenied
+	case xmlparser.StatusRejected:
+		return SummaryRejected
+	default:
+		return SummaryMissing
+	}
+}
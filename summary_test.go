@@ -0,0 +1,124 @@
+package nfce
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/marcelo-cunha/nfce-render/builder"
+)
+
+// cancelamento gera o evento de cancelamento registrado para a chave
+func cancelamento(t *testing.T, chave string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("xmlparser/testdata/cancelamento.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []byte(strings.ReplaceAll(string(data), "13240112345678000195650010000001231000001236", chave))
+}
+
+func TestSummaryStatus(t *testing.T) {
+	venda := func() *builder.Builder {
+		return builder.New().
+			AddItem(builder.Item{Descricao: "CAFE 500G", Quantidade: 2, ValorUnitario: 10.50, Desconto: 1}).
+			AddPagamento("01", 10).
+			AddPagamento("03", 15)
+	}
+
+	tests := []struct {
+		name         string
+		builder      *builder.Builder
+		cancelada    bool
+		want         SummaryStatus
+		contingencia bool
+	}{
+		{"autorizada", venda(), false, SummaryAuthorized, false},
+		{"cancelada", venda(), true, SummaryCancelled, false},
+		{"contingência sem protocolo", venda().ContingenciaOffline(""), false, SummaryContingency, true},
+		{"contingência cancelada", venda().ContingenciaOffline(""), true, SummaryCancelled, true},
+		{"contingência autorizada", venda().ContingenciaOffline("").Protocolo("100", "Autorizado o uso da NF-e"), false, SummaryAuthorized, true},
+		{"contingência denegada", venda().ContingenciaOffline("").Protocolo("110", "Uso Denegado"), false, SummaryDenied, true},
+		{"denegada", venda().Protocolo("110", "Uso Denegado"), false, SummaryDenied, false},
+		{"rejeitada", venda().Protocolo("225", "Rejeição: Falha no Schema XML"), false, SummaryRejected, false},
+		{"sem protocolo", venda().SemProtocolo(), false, SummaryMissing, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.builder.BuildXML()
+			if err != nil {
+				t.Fatal(err)
+			}
+			g, err := NewGenerator(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.cancelada {
+				if err := g.AddEventXML(cancelamento(t, g.nfe.GetChaveAcesso())); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			s := g.Summary()
+			if s.Status != tt.want {
+				t.Errorf("situação %q, esperada %q", s.Status, tt.want)
+			}
+			if s.Contingencia != tt.contingencia {
+				t.Errorf("contingência %v, esperada %v", s.Contingencia, tt.contingencia)
+			}
+		})
+	}
+}
+
+func TestSummaryPaymentsAndConsumer(t *testing.T) {
+	tests := []struct {
+		name      string
+		documento string
+		want      string
+	}{
+		{"CPF", "12345678909", "123.456.789-09"},
+		{"CNPJ", "11222333000181", "11.222.333/0001-81"},
+		{"não identificado", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := builder.New().
+				AddItem(builder.Item{Descricao: "CAFE 500G", Quantidade: 2, ValorUnitario: 10.50, Desconto: 1}).
+				AddPagamento("01", 10).
+				AddPagamento("03", 15)
+			if tt.documento != "" {
+				b.Consumidor(tt.documento, "FULANO DE TAL")
+			}
+			data, err := b.BuildXML()
+			if err != nil {
+				t.Fatal(err)
+			}
+			g, err := NewGenerator(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s := g.Summary()
+			if s.DocumentoConsumidor != tt.want {
+				t.Errorf("documento %q, esperado %q", s.DocumentoConsumidor, tt.want)
+			}
+			if nome := s.NomeConsumidor; (tt.documento != "") != (nome == "FULANO DE TAL") {
+				t.Errorf("nome do consumidor %q", nome)
+			}
+
+			pagamentos := []SummaryPayment{
+				{Codigo: "01", Descricao: "Dinheiro", Valor: 10},
+				{Codigo: "03", Descricao: "Cartão de Crédito", Valor: 15},
+			}
+			if !reflect.DeepEqual(s.Pagamentos, pagamentos) {
+				t.Errorf("pagamentos %+v, esperados %+v", s.Pagamentos, pagamentos)
+			}
+			if s.Total != 20 || s.Desconto != 1 || s.Troco != 5 {
+				t.Errorf("total %.2f, desconto %.2f, troco %.2f", s.Total, s.Desconto, s.Troco)
+			}
+		})
+	}
+}
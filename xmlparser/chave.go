@@ -0,0 +1,97 @@
+package xmlparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ChaveAcesso contém as partes que compõem a chave de acesso de 44 dígitos
+type ChaveAcesso struct {
+	CUF    string // código da UF do emitente
+	AAMM   string // ano e mês de emissão
+	CNPJ   string // CNPJ do emitente
+	Modelo string // modelo do documento (55 ou 65)
+	Serie  string // série, com 3 dígitos
+	Numero string // número do documento, com 9 dígitos
+	TpEmis string // forma de emissão
+	CNF    string // código numérico
+	DV     string // dígito verificador
+}
+
+// ParseChaveAcesso separa a chave de acesso em suas partes
+func ParseChaveAcesso(chave string) (ChaveAcesso, error) {
+	if len(chave) != 44 {
+		return ChaveAcesso{}, fmt.Errorf("chave de acesso deve ter 44 dígitos: %q", chave)
+	}
+	for _, c := range chave {
+		if c < '0' || c > '9' {
+			return ChaveAcesso{}, fmt.Errorf("chave de acesso deve conter apenas dígitos: %q", chave)
+		}
+	}
+
+	return ChaveAcesso{
+		CUF:    chave[0:2],
+		AAMM:   chave[2:6],
+		CNPJ:   chave[6:20],
+		Modelo: chave[20:22],
+		Serie:  chave[22:25],
+		Numero: chave[25:34],
+		TpEmis: chave[34:35],
+		CNF:    chave[35:43],
+		DV:     chave[43:44],
+	}, nil
+}
+
+// String monta a chave de acesso de 44 dígitos a partir das partes
+func (c ChaveAcesso) String() string {
+	return c.CUF + c.AAMM + c.CNPJ + c.Modelo + c.Serie + c.Numero + c.TpEmis + c.CNF + c.DV
+}
+
+// IsValid verifica se o dígito verificador confere com as demais partes
+func (c ChaveAcesso) IsValid() bool {
+	chave := c.String()
+	if len(chave) != 44 {
+		return false
+	}
+	dv, err := DigitoVerificador(chave[:43])
+	return err == nil && dv == c.DV
+}
+
+// FormatChaveAcesso agrupa a chave de acesso em blocos de 4 dígitos
+func FormatChaveAcesso(chave string) string {
+	var formatted strings.Builder
+	for i, c := range chave {
+		if i > 0 && i%4 == 0 {
+			formatted.WriteByte(' ')
+		}
+		formatted.WriteRune(c)
+	}
+	return formatted.String()
+}
+
+// DigitoVerificador calcula o dígito verificador (módulo 11) dos 43 primeiros
+// dígitos da chave de acesso
+func DigitoVerificador(chave string) (string, error) {
+	if len(chave) != 43 {
+		return "", fmt.Errorf("chave sem dígito verificador deve ter 43 dígitos: %q", chave)
+	}
+
+	soma, peso := 0, 2
+	for i := len(chave) - 1; i >= 0; i-- {
+		c := chave[i]
+		if c < '0' || c > '9' {
+			return "", fmt.Errorf("chave de acesso deve conter apenas dígitos: %q", chave)
+		}
+		soma += int(c-'0') * peso
+		if peso++; peso > 9 {
+			peso = 2
+		}
+	}
+
+	dv := 11 - soma%11
+	if dv >= 10 {
+		dv = 0
+	}
+	return strconv.Itoa(dv), nil
+}
@@ -0,0 +1,209 @@
+package xmlparser
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ufPorCodigo relaciona o código IBGE da UF (cUF) com a sua sigla
+var ufPorCodigo = map[string]string{
+	"11": "RO", "12": "AC", "13": "AM", "14": "RR", "15": "PA", "16": "AP", "17": "TO",
+	"21": "MA", "22": "PI", "23": "CE", "24": "RN", "25": "PB", "26": "PE", "27": "AL",
+	"28": "SE", "29": "BA", "31": "MG", "32": "ES", "33": "RJ", "35": "SP", "41": "PR",
+	"42": "SC", "43": "RS", "50": "MS", "51": "MT", "52": "GO", "53": "DF",
+}
+
+// offsetPorUF contém o deslocamento em horas do horário local de cada UF.
+// Desde 2019 não há horário de verão, então os fusos são fixos.
+var offsetPorUF = map[string]int{
+	"AC": -5,
+	"AM": -4, "MS": -4, "MT": -4, "RO": -4, "RR": -4,
+}
+
+// Brasilia é o fuso horário oficial de Brasília (-03:00)
+var Brasilia = time.FixedZone("-03", -3*60*60)
+
+// UFFromCode retorna a sigla da UF a partir do código IBGE (cUF)
+func UFFromCode(cUF string) string {
+	return ufPorCodigo[cUF]
+}
+
+// CodeFromUF retorna o código IBGE (cUF) a partir da sigla da UF
+func CodeFromUF(uf string) string {
+	uf = strings.ToUpper(uf)
+	for code, sigla := range ufPorCodigo {
+		if sigla == uf {
+			return code
+		}
+	}
+	return ""
+}
+
+// LocationForUF retorna o fuso horário local da UF informada.
+// UFs desconhecidas usam o horário de Brasília.
+func LocationForUF(uf string) *time.Location {
+	offset, ok := offsetPorUF[strings.ToUpper(uf)]
+	if !ok {
+		return Brasilia
+	}
+	return time.FixedZone(fmt.Sprintf("%03d", offset), offset*60*60)
+}
+
+// layoutsComOffset são os formatos de data e hora que trazem o fuso
+var layoutsComOffset = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05-0700",
+}
+
+// layoutsSemOffset são os formatos sem fuso, usados até a NF-e 3.10
+var layoutsSemOffset = []string{
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// ParseDateTime interpreta datas da NF-e com ou sem fuso horário.
+// Quando o valor não traz o fuso, ele é interpretado em loc.
+func ParseDateTime(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range layoutsComOffset {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	if loc == nil {
+		loc = Brasilia
+	}
+	for _, layout := range layoutsSemOffset {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("data inválida: %q", value)
+}
+
+// combineDateAndTime junta uma data (dEmi/dSaiEnt) e uma hora (hSaiEnt)
+// no formato usado pela NF-e 2.00
+func combineDateAndTime(date, clock string, loc *time.Location) (time.Time, error) {
+	if strings.TrimSpace(clock) == "" {
+		return ParseDateTime(date, loc)
+	}
+	return ParseDateTime(strings.TrimSpace(date)+"T"+strings.TrimSpace(clock), loc)
+}
+
+// UnmarshalXML interpreta a identificação aceitando tanto dhEmi/dhSaiEnt
+// quanto os campos dEmi, dSaiEnt e hSaiEnt das versões anteriores à 3.10
+func (ide *Ide) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type ideAlias Ide
+	aux := struct {
+		*ideAlias
+		DHEmi    string `xml:"dhEmi"`
+		DHSaiEnt string `xml:"dhSaiEnt"`
+		DHCont   string `xml:"dhCont"`
+	}{ideAlias: (*ideAlias)(ide)}
+
+	if err := d.DecodeElement(&aux, &start); err != nil {
+		return err
+	}
+
+	values := map[string]string{"dhEmi": aux.DHEmi, "dhSaiEnt": aux.DHSaiEnt, "dhCont": aux.DHCont}
+	_, err := ide.resolveDates(func(name string) (string, bool) {
+		return values[name], values[name] != ""
+	})
+	return err
+}
+
+// afterDecode faz o mesmo que UnmarshalXML no parse que preserva o XML,
+// marcando dhEmi/dhSaiEnt como calculados quando vêm dos campos antigos
+func (ide *Ide) afterDecode(n *node) error {
+	derived, err := ide.resolveDates(n.text)
+	for _, name := range derived {
+		n.derive(name)
+	}
+	return err
+}
+
+// resolveDates reinterpreta as datas sem fuso no horário local do emitente
+// e preenche dhEmi/dhSaiEnt a partir de dEmi, dSaiEnt e hSaiEnt, usados
+// nas versões anteriores à 3.10. text retorna o texto original de cada
+// data; o retorno são os campos calculados a partir dos campos antigos.
+func (ide *Ide) resolveDates(text func(name string) (string, bool)) ([]string, error) {
+	loc := LocationForUF(UFFromCode(ide.CUF))
+
+	parse := func(name string) (*time.Time, error) {
+		value, ok := text(name)
+		if !ok {
+			return nil, nil
+		}
+		t, err := ParseDateTime(value, loc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return &t, nil
+	}
+
+	var derived []string
+	dhEmi, err := parse("dhEmi")
+	if err != nil {
+		return nil, err
+	}
+	if dhEmi != nil {
+		ide.DHEmi = *dhEmi
+	} else if ide.DEmi != "" {
+		if ide.DHEmi, err = ParseDateTime(ide.DEmi, loc); err != nil {
+			return nil, fmt.Errorf("dEmi: %w", err)
+		}
+		derived = append(derived, "dhEmi")
+	}
+
+	dhSaiEnt, err := parse("dhSaiEnt")
+	if err != nil {
+		return nil, err
+	}
+	if dhSaiEnt != nil {
+		ide.DHSaiEnt = dhSaiEnt
+	} else if ide.DSaiEnt != "" {
+		t, err := combineDateAndTime(ide.DSaiEnt, ide.HSaiEnt, loc)
+		if err != nil {
+			return nil, fmt.Errorf("dSaiEnt: %w", err)
+		}
+		ide.DHSaiEnt = &t
+		derived = append(derived, "dhSaiEnt")
+	}
+
+	dhCont, err := parse("dhCont")
+	if err != nil {
+		return nil, err
+	}
+	if dhCont != nil {
+		ide.DHCont = dhCont
+	}
+
+	return derived, nil
+}
+
+// UnmarshalXML interpreta o protocolo aceitando dhRecbto sem fuso horário,
+// caso em que o horário é o de Brasília
+func (p *InfProt) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type infProtAlias InfProt
+	aux := struct {
+		*infProtAlias
+		DhRecbto string `xml:"dhRecbto"`
+	}{infProtAlias: (*infProtAlias)(p)}
+
+	if err := d.DecodeElement(&aux, &start); err != nil {
+		return err
+	}
+
+	var err error
+	p.DhRecbto, err = ParseDateTime(aux.DhRecbto, Brasilia)
+	if err != nil {
+		return fmt.Errorf("dhRecbto: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package xmlparser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+)
+
+// ErrMalformedXML indica que o XML não pôde ser interpretado
+var ErrMalformedXML = errors.New("XML malformado")
+
+// MalformedXMLError descreve onde o parse do XML falhou
+type MalformedXMLError struct {
+	Line   int
+	Column int
+	Err    error
+}
+
+// Error implementa a interface error
+func (e *MalformedXMLError) Error() string {
+	return fmt.Sprintf("XML malformado (linha %d, coluna %d): %v", e.Line, e.Column, e.Err)
+}
+
+// Unwrap retorna o erro original do decoder
+func (e *MalformedXMLError) Unwrap() error {
+	return e.Err
+}
+
+// Is permite comparar o erro com ErrMalformedXML usando errors.Is
+func (e *MalformedXMLError) Is(target error) bool {
+	return target == ErrMalformedXML
+}
+
+// decode faz o parse do XML registrando a posição em caso de erro
+func decode(xmlContent []byte, v any) error {
+	d := xml.NewDecoder(bytes.NewReader(xmlContent))
+	if err := d.Decode(v); err != nil {
+		line, column := d.InputPos()
+		var syntaxErr *xml.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line = syntaxErr.Line
+		}
+		return &MalformedXMLError{Line: line, Column: column, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package xmlparser
+
+import (
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Tipos de evento da NF-e tratados pela biblioteca
+const (
+	TpEventoCancelamento             = "110111"
+	TpEventoCancelamentoSubstituicao = "110112"
+)
+
+// ProcEventoNFe representa o XML de um evento da NF-e processado pela SEFAZ
+type ProcEventoNFe struct {
+	XMLName   xml.Name  `xml:"procEventoNFe"`
+	Versao    string    `xml:"versao,attr"`
+	Xmlns     string    `xml:"xmlns,attr"`
+	Evento    Evento    `xml:"evento"`
+	RetEvento RetEvento `xml:"retEvento"`
+
+	doc *document
+}
+
+// Evento contém o pedido de registro do evento
+type Evento struct {
+	Versao    string    `xml:"versao,attr"`
+	InfEvento InfEvento `xml:"infEvento"`
+}
+
+// InfEvento contém as informações do evento
+type InfEvento struct {
+	ID         string    `xml:"Id,attr"`
+	COrgao     string    `xml:"cOrgao"`
+	TpAmb      string    `xml:"tpAmb"`
+	CNPJ       string    `xml:"CNPJ,omitempty"`
+	CPF        string    `xml:"CPF,omitempty"`
+	ChNFe      string    `xml:"chNFe"`
+	DhEvento   time.Time `xml:"dhEvento"`
+	TpEvento   string    `xml:"tpEvento"`
+	NSeqEvento string    `xml:"nSeqEvento"`
+	VerEvento  string    `xml:"verEvento"`
+	DetEvento  DetEvento `xml:"detEvento"`
+}
+
+// DetEvento contém os dados específicos do evento de cancelamento
+type DetEvento struct {
+	Versao      string `xml:"versao,attr"`
+	DescEvento  string `xml:"descEvento"`
+	COrgaoAutor string `xml:"cOrgaoAutor,omitempty"`
+	TpAutor     string `xml:"tpAutor,omitempty"`
+	VerAplic    string `xml:"verAplic,omitempty"`
+	NProt       string `xml:"nProt"`
+	XJust       string `xml:"xJust"`
+	ChNFeRef    string `xml:"chNFeRef,omitempty"` // cancelamento por substituição
+}
+
+// RetEvento contém o retorno da SEFAZ para o evento
+type RetEvento struct {
+	Versao    string       `xml:"versao,attr"`
+	InfEvento InfRetEvento `xml:"infEvento"`
+}
+
+// InfRetEvento contém as informações do registro do evento
+type InfRetEvento struct {
+	TpAmb       string    `xml:"tpAmb"`
+	VerAplic    string    `xml:"verAplic"`
+	COrgao      string    `xml:"cOrgao"`
+	CStat       string    `xml:"cStat"`
+	XMotivo     string    `xml:"xMotivo"`
+	ChNFe       string    `xml:"chNFe"`
+	TpEvento    string    `xml:"tpEvento"`
+	XEvento     string    `xml:"xEvento"`
+	NSeqEvento  string    `xml:"nSeqEvento"`
+	DhRegEvento time.Time `xml:"dhRegEvento"`
+	NProt       string    `xml:"nProt"`
+}
+
+// IsCancelamento verifica se o evento é um cancelamento (110111 ou 110112)
+func (ev *ProcEventoNFe) IsCancelamento() bool {
+	switch ev.Evento.InfEvento.TpEvento {
+	case TpEventoCancelamento, TpEventoCancelamentoSubstituicao:
+		return true
+	}
+	return false
+}
+
+// IsRegistrado verifica se a SEFAZ registrou o evento (cStat 135, 136 ou 155)
+func (ev *ProcEventoNFe) IsRegistrado() bool {
+	switch ev.RetEvento.InfEvento.CStat {
+	case "135", "136", "155":
+		return true
+	}
+	return false
+}
+
+// GetChaveAcesso retorna a chave de acesso da NF-e a que o evento se refere
+func (ev *ProcEventoNFe) GetChaveAcesso() string {
+	return ev.Evento.InfEvento.ChNFe
+}
+
+// GetProtocolo retorna o protocolo de registro do evento
+func (ev *ProcEventoNFe) GetProtocolo() string {
+	return ev.RetEvento.InfEvento.NProt
+}
+
+// GetDataRegistro retorna a data de registro do evento na SEFAZ,
+// ou a data do evento quando o retorno não a informa
+func (ev *ProcEventoNFe) GetDataRegistro() time.Time {
+	if !ev.RetEvento.InfEvento.DhRegEvento.IsZero() {
+		return ev.RetEvento.InfEvento.DhRegEvento
+	}
+	return ev.Evento.InfEvento.DhEvento
+}
+
+// GetJustificativa retorna a justificativa do evento
+func (ev *ProcEventoNFe) GetJustificativa() string {
+	return ev.Evento.InfEvento.DetEvento.XJust
+}
+
+// ParseEventoXML faz o parse do XML de um evento da NF-e (procEventoNFe)
+func ParseEventoXML(xmlContent []byte) (*ProcEventoNFe, error) {
+	return ParseEventoXMLWithOptions(xmlContent, ParseOptions{})
+}
+
+// ParseEventoXMLWithOptions faz o parse do XML de um evento da NF-e
+// (procEventoNFe) conforme as opções
+func ParseEventoXMLWithOptions(xmlContent []byte, opts ParseOptions) (*ProcEventoNFe, error) {
+	var ev ProcEventoNFe
+	var v any = &ev
+	if opts.Preserve {
+		v = (*preservedEvento)(&ev)
+	}
+	if err := decode(xmlContent, v); err != nil {
+		return nil, fmt.Errorf("erro ao fazer parse do XML do evento: %w", err)
+	}
+	return &ev, nil
+}
+
+// preservedEvento faz o parse do evento preservando o conteúdo não modelado
+type preservedEvento ProcEventoNFe
+
+// UnmarshalXML implementa xml.Unmarshaler
+func (p *preservedEvento) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if err := checkRoot(start, "procEventoNFe"); err != nil {
+		return err
+	}
+	doc, err := unmarshalDocument(d, start, reflect.ValueOf(p).Elem(), "")
+	if err != nil {
+		return err
+	}
+	p.doc = doc
+	return nil
+}
+
+// Marshal escreve o evento como XML. Para eventos obtidos com Preserve, o
+// conteúdo não modelado, como a assinatura, é mantido.
+func (ev *ProcEventoNFe) Marshal(opts MarshalOptions) ([]byte, error) {
+	return marshalDocument(ev.doc, "procEventoNFe", reflect.ValueOf(ev).Elem(), "", opts)
+}
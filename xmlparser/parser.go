@@ -1,9 +1,12 @@
 package xmlparser
 
 import (
+	"bytes"
 	"encoding/xml"
 	"fmt"
+	"reflect"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -14,6 +17,8 @@ type NFeProc struct {
 	Xmlns   string   `xml:"xmlns,attr"`
 	NFe     NFe      `xml:"NFe"`
 	ProtNFe ProtNFe  `xml:"protNFe"`
+
+	doc *document
 }
 
 // NFe representa a estrutura da Nota Fiscal Eletrônica
@@ -48,6 +53,9 @@ type Ide struct {
 	NNF      string     `xml:"nNF"`
 	DHEmi    time.Time  `xml:"dhEmi"`
 	DHSaiEnt *time.Time `xml:"dhSaiEnt,omitempty"`
+	DEmi     string     `xml:"dEmi,omitempty"`    // NF-e 2.00
+	DSaiEnt  string     `xml:"dSaiEnt,omitempty"` // NF-e 2.00
+	HSaiEnt  string     `xml:"hSaiEnt,omitempty"` // NF-e 2.00
 	TpNF     string     `xml:"tpNF"`
 	IDDest   string     `xml:"idDest"`
 	CMunFG   string     `xml:"cMunFG"`
@@ -60,6 +68,8 @@ type Ide struct {
 	IndPres  string     `xml:"indPres"`
 	ProcEmi  string     `xml:"procEmi"`
 	VerProc  string     `xml:"verProc"`
+	DHCont   *time.Time `xml:"dhCont,omitempty"`
+	XJust    string     `xml:"xJust,omitempty"`
 }
 
 // Emit contém as informações do emitente
@@ -131,6 +141,7 @@ type Prod struct {
 	UTrib    string  `xml:"uTrib"`
 	QTrib    float64 `xml:"qTrib"`
 	VUnTrib  float64 `xml:"vUnTrib"`
+	VDesc    float64 `xml:"vDesc,omitempty"`
 	IndTot   string  `xml:"indTot"`
 }
 
@@ -628,9 +639,13 @@ func (nfe *NFeProc) GetQRCode() string {
 	return ""
 }
 
-// GetChaveAcesso retorna a chave de acesso da NF-e
+// GetChaveAcesso retorna a chave de acesso da NF-e. Sem protocolo (por exemplo
+// em contingência offline), a chave é obtida do atributo Id de infNFe.
 func (nfe *NFeProc) GetChaveAcesso() string {
-	return nfe.ProtNFe.InfProt.ChNFe
+	if nfe.ProtNFe.InfProt.ChNFe != "" {
+		return nfe.ProtNFe.InfProt.ChNFe
+	}
+	return strings.TrimPrefix(nfe.NFe.InfNFe.ID, "NFe")
 }
 
 // GetNumeroNF retorna o número da NF-e
@@ -648,6 +663,14 @@ func (nfe *NFeProc) GetDataEmissao() time.Time {
 	return nfe.NFe.InfNFe.Ide.DHEmi
 }
 
+// GetUF retorna a UF do emitente, usando o cUF quando o endereço não a informa
+func (nfe *NFeProc) GetUF() string {
+	if uf := nfe.NFe.InfNFe.Emit.EnderEmit.UF; uf != "" {
+		return uf
+	}
+	return UFFromCode(nfe.NFe.InfNFe.Ide.CUF)
+}
+
 // GetValorTotal retorna o valor total da NF-e
 func (nfe *NFeProc) GetValorTotal() float64 {
 	return nfe.NFe.InfNFe.Total.ICMSTot.VNF
@@ -691,15 +714,124 @@ func FormatQuantity(value float64) string {
 	return formatted
 }
 
+// ParseOptions define como o XML é interpretado
+type ParseOptions struct {
+	// Preserve guarda os elementos e atributos que o modelo não representa
+	// (Signature, infRespTec, comb, grupos de notas técnicas mais recentes),
+	// a ordem original dos elementos e o texto original dos valores, para
+	// que Marshal reescreva o XML sem perda de informação
+	Preserve bool
+}
+
 // ParseXML faz o parse do XML da NF-e
 func ParseXML(xmlContent []byte) (*NFeProc, error) {
+	return ParseXMLWithOptions(xmlContent, ParseOptions{})
+}
+
+// ParseXMLWithOptions faz o parse do XML da NF-e conforme as opções
+func ParseXMLWithOptions(xmlContent []byte, opts ParseOptions) (*NFeProc, error) {
 	var nfe NFeProc
-	if err := xml.Unmarshal(xmlContent, &nfe); err != nil {
-		return nil, fmt.Errorf("erro ao fazer parse do XML: %w", err)
+
+	// NFC-e emitidas em contingência offline circulam sem o nfeProc
+	// até serem autorizadas
+	if rootElement(xmlContent) == "NFe" {
+		if opts.Preserve {
+			if err := decode(xmlContent, (*preservedNFe)(&nfe)); err != nil {
+				return nil, err
+			}
+			return &nfe, nil
+		}
+		if err := decode(xmlContent, &nfe.NFe); err != nil {
+			return nil, err
+		}
+		// Sem o document, Marshal ainda precisa saber que não há nfeProc
+		nfe.doc = &document{bare: true}
+		return &nfe, nil
+	}
+
+	if opts.Preserve {
+		if err := decode(xmlContent, (*preservedNFeProc)(&nfe)); err != nil {
+			return nil, err
+		}
+		return &nfe, nil
+	}
+	if err := decode(xmlContent, &nfe); err != nil {
+		return nil, err
 	}
 	return &nfe, nil
 }
 
+// Marshal escreve a NF-e como XML. Para documentos obtidos com Preserve, o
+// resultado é semanticamente idêntico ao XML original, incluindo elementos
+// não modelados como Signature e infRespTec; nos demais, apenas o conteúdo
+// modelado é escrito.
+func (nfe *NFeProc) Marshal(opts MarshalOptions) ([]byte, error) {
+	if nfe.doc != nil && nfe.doc.bare {
+		return marshalDocument(nfe.doc, "NFe", reflect.ValueOf(&nfe.NFe).Elem(), nfePath, opts)
+	}
+	return marshalDocument(nfe.doc, "nfeProc", reflect.ValueOf(nfe).Elem(), "", opts)
+}
+
+// nfePath é o caminho do elemento NFe dentro do nfeProc
+const nfePath = "/NFe[0]"
+
+// preservedNFeProc faz o parse do nfeProc preservando o conteúdo não modelado
+type preservedNFeProc NFeProc
+
+// UnmarshalXML implementa xml.Unmarshaler
+func (p *preservedNFeProc) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if err := checkRoot(start, "nfeProc"); err != nil {
+		return err
+	}
+	doc, err := unmarshalDocument(d, start, reflect.ValueOf(p).Elem(), "")
+	if err != nil {
+		return err
+	}
+	p.doc = doc
+	return nil
+}
+
+// preservedNFe faz o parse de um XML cuja raiz é o elemento NFe,
+// preservando o conteúdo não modelado
+type preservedNFe NFeProc
+
+// UnmarshalXML implementa xml.Unmarshaler
+func (p *preservedNFe) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if err := checkRoot(start, "NFe"); err != nil {
+		return err
+	}
+	doc, err := unmarshalDocument(d, start, reflect.ValueOf(&p.NFe).Elem(), nfePath)
+	if err != nil {
+		return err
+	}
+	doc.bare = true
+	p.doc = doc
+	return nil
+}
+
+// checkRoot verifica o nome do elemento raiz, com a mesma mensagem do
+// encoding/xml para o campo XMLName
+func checkRoot(start xml.StartElement, name string) error {
+	if start.Name.Local != name {
+		return fmt.Errorf("expected element type <%s> but have <%s>", name, start.Name.Local)
+	}
+	return nil
+}
+
+// rootElement retorna o nome do elemento raiz do XML
+func rootElement(xmlContent []byte) string {
+	d := xml.NewDecoder(bytes.NewReader(xmlContent))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
+
 // GetPaymentMethodDescription retorna a descrição do método de pagamento
 func GetPaymentMethodDescription(tPag string) string {
 	switch tPag {
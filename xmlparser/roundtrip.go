@@ -0,0 +1,694 @@
+package xmlparser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MarshalOptions define como o XML é escrito
+type MarshalOptions struct {
+	Indent         string // indentação; vazio gera o XML minificado
+	Header         bool   // inclui a declaração <?xml ...?>
+	DiscardUnknown bool   // descarta elementos e atributos não modelados
+}
+
+// document guarda o que o modelo não representa diretamente: elementos e
+// atributos desconhecidos, a ordem original dos filhos e o texto original
+// dos valores. Com isso o XML pode ser reescrito sem perda de informação.
+type document struct {
+	nodes    map[string]*node
+	prefixes map[string]string // namespace -> prefixo declarado
+	bare     bool              // o XML original não tinha o nfeProc
+}
+
+// node registra um elemento do XML original
+type node struct {
+	attrs    []xml.Attr
+	children []child
+	derived  map[string]bool // campos calculados que não existiam no XML
+}
+
+// child é um filho de node, na ordem em que apareceu
+type child struct {
+	name  string
+	raw   *rawElement // elemento não modelado
+	xmlns string      // namespace a declarar no elemento não modelado
+	text  *string     // texto original de um valor simples
+}
+
+// rawElement é um elemento não modelado, mantido como XML bruto
+type rawElement struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Inner   []byte     `xml:",innerxml"`
+}
+
+// text retorna o texto original do primeiro filho com o nome informado
+func (n *node) text(name string) (string, bool) {
+	for _, c := range n.children {
+		if c.name == name && c.text != nil {
+			return *c.text, true
+		}
+	}
+	return "", false
+}
+
+// derive marca um campo como calculado a partir de outros elementos
+func (n *node) derive(name string) {
+	if n.derived == nil {
+		n.derived = map[string]bool{}
+	}
+	n.derived[name] = true
+}
+
+// afterDecoder é implementado pelos tipos que ajustam valores após o parse
+type afterDecoder interface {
+	afterDecode(n *node) error
+}
+
+// fieldInfo descreve um campo do modelo segundo a tag xml
+type fieldInfo struct {
+	index     int
+	name      string
+	attr      bool
+	omitempty bool
+}
+
+// structInfo descreve os campos xml de uma struct do modelo
+type structInfo struct {
+	fields []fieldInfo
+	elems  map[string]int
+	attrs  map[string]int
+}
+
+var (
+	structInfos sync.Map
+	timeType    = reflect.TypeOf(time.Time{})
+	xmlNameType = reflect.TypeOf(xml.Name{})
+)
+
+// getStructInfo lê as tags xml da struct, com cache por tipo
+func getStructInfo(t reflect.Type) *structInfo {
+	if info, ok := structInfos.Load(t); ok {
+		return info.(*structInfo)
+	}
+
+	info := &structInfo{elems: map[string]int{}, attrs: map[string]int{}}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("xml")
+		if f.PkgPath != "" || tag == "-" {
+			continue
+		}
+		if f.Type == xmlNameType {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+
+		fi := fieldInfo{index: i, name: parts[0]}
+		if fi.name == "" {
+			fi.name = f.Name
+		}
+		for _, opt := range parts[1:] {
+			switch opt {
+			case "attr":
+				fi.attr = true
+			case "omitempty":
+				fi.omitempty = true
+			}
+		}
+
+		if fi.attr {
+			info.attrs[fi.name] = len(info.fields)
+		} else {
+			info.elems[fi.name] = len(info.fields)
+		}
+		info.fields = append(info.fields, fi)
+	}
+
+	structInfos.Store(t, info)
+	return info
+}
+
+// isLeaf verifica se o tipo é um valor simples (texto, número ou data)
+func isLeaf(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t == timeType || t.Kind() != reflect.Struct
+}
+
+// unmarshalDocument faz o parse de um elemento e de todos os seus filhos,
+// registrando no document tudo o que o modelo não representa
+func unmarshalDocument(d *xml.Decoder, start xml.StartElement, v reflect.Value, path string) (*document, error) {
+	doc := &document{nodes: map[string]*node{}, prefixes: map[string]string{}}
+	if err := doc.decodeStruct(d, start, v, path); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// decodeStruct preenche a struct v com o conteúdo do elemento start
+func (doc *document) decodeStruct(d *xml.Decoder, start xml.StartElement, v reflect.Value, path string) error {
+	info := getStructInfo(v.Type())
+	n := &node{}
+
+	for _, a := range start.Attr {
+		if a.Name.Space == "xmlns" {
+			doc.prefixes[a.Value] = a.Name.Local
+		}
+		if i, ok := info.attrs[a.Name.Local]; ok && a.Name.Space == "" {
+			if err := setLeaf(v.Field(info.fields[i].index), a.Value); err != nil {
+				return fmt.Errorf("%s: %w", a.Name.Local, err)
+			}
+			continue
+		}
+		n.attrs = append(n.attrs, a)
+	}
+
+	counts := map[string]int{}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := t.Name.Local
+			i, ok := info.elems[name]
+			if !ok {
+				raw := &rawElement{}
+				if err := d.DecodeElement(raw, &t); err != nil {
+					return err
+				}
+				c := child{name: name, raw: raw}
+				if t.Name.Space != start.Name.Space && !hasXmlns(raw.Attrs) {
+					c.xmlns = t.Name.Space
+				}
+				n.children = append(n.children, c)
+				continue
+			}
+
+			childPath := fmt.Sprintf("%s/%s[%d]", path, name, counts[name])
+			counts[name]++
+
+			target := v.Field(info.fields[i].index)
+			if target.Kind() == reflect.Slice {
+				target.Set(reflect.Append(target, reflect.New(target.Type().Elem()).Elem()))
+				target = target.Index(target.Len() - 1)
+			}
+
+			if isLeaf(target.Type()) {
+				text, err := readText(d)
+				if err != nil {
+					return err
+				}
+				if err := setLeaf(target, text); err != nil {
+					return fmt.Errorf("%s: %w", name, err)
+				}
+				n.children = append(n.children, child{name: name, text: &text})
+				continue
+			}
+
+			if target.Kind() == reflect.Pointer {
+				if target.IsNil() {
+					target.Set(reflect.New(target.Type().Elem()))
+				}
+				target = target.Elem()
+			}
+			if err := doc.decodeStruct(d, t, target, childPath); err != nil {
+				return err
+			}
+			n.children = append(n.children, child{name: name})
+
+		case xml.EndElement:
+			if hook, ok := v.Addr().Interface().(afterDecoder); ok {
+				if err := hook.afterDecode(n); err != nil {
+					return err
+				}
+			}
+			doc.nodes[path] = n
+			return nil
+		}
+	}
+}
+
+// readText lê o conteúdo textual de um elemento simples
+func readText(d *xml.Decoder) (string, error) {
+	var text strings.Builder
+	depth := 0
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			if depth == 0 {
+				text.Write(t)
+			}
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			if depth == 0 {
+				return text.String(), nil
+			}
+			depth--
+		}
+	}
+}
+
+// hasXmlns verifica se os atributos declaram o namespace padrão
+func hasXmlns(attrs []xml.Attr) bool {
+	for _, a := range attrs {
+		if a.Name.Space == "" && a.Name.Local == "xmlns" {
+			return true
+		}
+	}
+	return false
+}
+
+// setLeaf converte o texto do XML para o tipo do campo
+func setLeaf(v reflect.Value, text string) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	if v.Type() == timeType {
+		t, err := ParseDateTime(text, Brasilia)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	trimmed := strings.TrimSpace(text)
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(text)
+	case reflect.Float32, reflect.Float64:
+		if trimmed == "" {
+			v.SetFloat(0)
+			return nil
+		}
+		f, err := strconv.ParseFloat(trimmed, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if trimmed == "" {
+			v.SetInt(0)
+			return nil
+		}
+		i, err := strconv.ParseInt(trimmed, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(trimmed)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("tipo não suportado: %s", v.Type())
+	}
+	return nil
+}
+
+// marshalDocument escreve v como XML, reaproveitando o que foi registrado no parse
+func marshalDocument(doc *document, name string, v reflect.Value, path string, opts MarshalOptions) ([]byte, error) {
+	if doc == nil {
+		doc = &document{}
+	}
+	w := &xmlWriter{indent: opts.Indent, prefixes: doc.prefixes}
+	if opts.Header {
+		w.buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+	}
+	if err := doc.encodeStruct(w, name, v, path, opts); err != nil {
+		return nil, err
+	}
+	if opts.Indent != "" {
+		w.buf.WriteByte('\n')
+	}
+	return w.buf.Bytes(), nil
+}
+
+// encodeStruct escreve a struct v como o elemento name
+func (doc *document) encodeStruct(w *xmlWriter, name string, v reflect.Value, path string, opts MarshalOptions) error {
+	info := getStructInfo(v.Type())
+	n := doc.nodes[path]
+
+	var attrs []xml.Attr
+	for _, fi := range info.fields {
+		if !fi.attr {
+			continue
+		}
+		fv := v.Field(fi.index)
+		if fv.IsZero() {
+			continue
+		}
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: fi.name}, Value: leafText(fi.name, fv)})
+	}
+	if n != nil && !opts.DiscardUnknown {
+		attrs = append(attrs, n.attrs...)
+	}
+
+	w.startTag(name, attrs)
+	hasChildren := false
+
+	emitted := make([]int, len(info.fields))
+	flushed := make([]bool, len(info.fields))
+	recorded := make([]int, len(info.fields))
+	if n != nil {
+		for _, c := range n.children {
+			if i, ok := info.elems[c.name]; ok {
+				recorded[i]++
+			}
+		}
+	}
+
+	// emit escreve os valores do campo i a partir do índice atual
+	emit := func(i int, limit int, c *child) error {
+		fi := info.fields[i]
+		// Em documentos vindos de um XML, campos vazios que não existiam
+		// no original são omitidos; nos demais vale o omitempty da tag
+		skipZero := fi.omitempty
+		if n != nil {
+			skipZero = recorded[i] == 0
+		}
+		values := fieldValues(v.Field(fi.index), skipZero)
+		for emitted[i] < len(values) && emitted[i] < limit {
+			fv := values[emitted[i]]
+			childPath := fmt.Sprintf("%s/%s[%d]", path, fi.name, emitted[i])
+			emitted[i]++
+			hasChildren = true
+
+			if isLeaf(fv.Type()) {
+				text := leafText(fi.name, fv)
+				if c != nil && c.text != nil && leafUnchanged(fv, *c.text) {
+					text = *c.text
+				}
+				w.leaf(fi.name, text)
+				continue
+			}
+			if fv.Kind() == reflect.Pointer {
+				fv = fv.Elem()
+			}
+			if err := doc.encodeStruct(w, fi.name, fv, childPath, opts); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// flush escreve os campos que não existiam no XML original e que vêm
+	// antes do campo atual na ordem do modelo
+	flush := func(upto int) error {
+		for i := 0; i < upto; i++ {
+			fi := info.fields[i]
+			if fi.attr || flushed[i] || recorded[i] > 0 || (n != nil && n.derived[fi.name]) {
+				continue
+			}
+			flushed[i] = true
+			if err := emit(i, math.MaxInt, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if n != nil {
+		for idx := range n.children {
+			c := &n.children[idx]
+			if c.raw != nil {
+				if !opts.DiscardUnknown {
+					w.raw(c.raw, c.xmlns)
+					hasChildren = true
+				}
+				continue
+			}
+			i, ok := info.elems[c.name]
+			if !ok {
+				continue
+			}
+			if err := flush(i); err != nil {
+				return err
+			}
+			if err := emit(i, emitted[i]+1, c); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := flush(len(info.fields)); err != nil {
+		return err
+	}
+	for i, fi := range info.fields {
+		if fi.attr || (n != nil && n.derived[fi.name]) {
+			continue
+		}
+		if err := emit(i, math.MaxInt, nil); err != nil {
+			return err
+		}
+	}
+
+	w.endTag(name, hasChildren)
+	return nil
+}
+
+// fieldValues retorna os valores a escrever para o campo. Com skipZero,
+// valores vazios são omitidos.
+func fieldValues(fv reflect.Value, skipZero bool) []reflect.Value {
+	switch fv.Kind() {
+	case reflect.Slice:
+		values := make([]reflect.Value, fv.Len())
+		for i := range values {
+			values[i] = fv.Index(i)
+		}
+		return values
+	case reflect.Pointer:
+		if fv.IsNil() || (skipZero && fv.Elem().IsZero()) {
+			return nil
+		}
+		return []reflect.Value{fv}
+	}
+	if skipZero && fv.IsZero() {
+		return nil
+	}
+	return []reflect.Value{fv}
+}
+
+// leafUnchanged verifica se o valor ainda corresponde ao texto original
+func leafUnchanged(fv reflect.Value, text string) bool {
+	parsed := reflect.New(fv.Type()).Elem()
+	if setLeaf(parsed, text) != nil {
+		return false
+	}
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() || parsed.IsNil() {
+			return fv.IsNil() == parsed.IsNil()
+		}
+		fv, parsed = fv.Elem(), parsed.Elem()
+	}
+	if fv.Type() == timeType {
+		// Datas sem fuso são reinterpretadas no fuso da UF após o parse,
+		// então apenas o horário local é comparado
+		a, b := fv.Interface().(time.Time), parsed.Interface().(time.Time)
+		const wall = "2006-01-02T15:04:05.999999999"
+		_, offsetA := a.Zone()
+		_, offsetB := b.Zone()
+		return a.Format(wall) == b.Format(wall) && (offsetA == offsetB || !hasOffset(text))
+	}
+	return reflect.DeepEqual(fv.Interface(), parsed.Interface())
+}
+
+// hasOffset verifica se uma data do XML informa o fuso horário
+func hasOffset(text string) bool {
+	text = strings.TrimSpace(text)
+	if strings.HasSuffix(text, "Z") {
+		return true
+	}
+	i := strings.LastIndexAny(text, "+-")
+	return i > len("2006-01-02") && strings.Contains(text[i:], ":")
+}
+
+// leafText formata um valor simples no padrão dos XMLs da NF-e
+func leafText(name string, v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if strings.HasPrefix(name, "d") && !strings.HasPrefix(name, "dh") {
+			return t.Format("2006-01-02")
+		}
+		return t.Format("2006-01-02T15:04:05-07:00")
+	}
+
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return formatDecimalField(name, v.Float())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	default:
+		return v.String()
+	}
+}
+
+// formatDecimalField formata um decimal com as casas exigidas pelo leiaute
+// da NF-e para o campo: quantidades com 4 casas, valores unitários com até
+// 10, pesos com 3, percentuais com 2 a 4 e demais valores com 2
+func formatDecimalField(name string, value float64) string {
+	switch {
+	case strings.HasPrefix(name, "q"):
+		return formatDecimal(value, 4, 4)
+	case strings.HasPrefix(name, "vUn"):
+		return formatDecimal(value, 2, 10)
+	case strings.HasPrefix(name, "peso"):
+		return formatDecimal(value, 3, 3)
+	case strings.HasPrefix(name, "p"):
+		return formatDecimal(value, 2, 4)
+	default:
+		return formatDecimal(value, 2, 2)
+	}
+}
+
+// formatDecimal formata value com no mínimo min e no máximo max casas decimais
+func formatDecimal(value float64, min, max int) string {
+	s := strconv.FormatFloat(value, 'f', max, 64)
+	if min == max {
+		return s
+	}
+	dot := strings.IndexByte(s, '.')
+	end := len(s)
+	for end > dot+1+min && s[end-1] == '0' {
+		end--
+	}
+	return s[:end]
+}
+
+// xmlWriter escreve o XML com ou sem indentação
+type xmlWriter struct {
+	buf      bytes.Buffer
+	indent   string
+	depth    int
+	prefixes map[string]string
+}
+
+// newline quebra a linha e indenta quando a indentação está ativa
+func (w *xmlWriter) newline() {
+	if w.indent == "" || w.buf.Len() == 0 {
+		return
+	}
+	w.buf.WriteByte('\n')
+	w.buf.WriteString(strings.Repeat(w.indent, w.depth))
+}
+
+// startTag abre um elemento
+func (w *xmlWriter) startTag(name string, attrs []xml.Attr) {
+	w.newline()
+	w.buf.WriteByte('<')
+	w.buf.WriteString(name)
+	w.writeAttrs(attrs)
+	w.buf.WriteByte('>')
+	w.depth++
+}
+
+// endTag fecha um elemento
+func (w *xmlWriter) endTag(name string, hasChildren bool) {
+	w.depth--
+	if hasChildren {
+		w.newline()
+	}
+	w.buf.WriteString("</")
+	w.buf.WriteString(name)
+	w.buf.WriteByte('>')
+}
+
+// leaf escreve um elemento simples com texto
+func (w *xmlWriter) leaf(name, text string) {
+	w.newline()
+	w.buf.WriteByte('<')
+	w.buf.WriteString(name)
+	w.buf.WriteByte('>')
+	escape(&w.buf, text, false)
+	w.buf.WriteString("</")
+	w.buf.WriteString(name)
+	w.buf.WriteByte('>')
+}
+
+// raw escreve um elemento não modelado com o conteúdo original
+func (w *xmlWriter) raw(r *rawElement, xmlns string) {
+	w.newline()
+	w.buf.WriteByte('<')
+	w.buf.WriteString(r.XMLName.Local)
+	if xmlns != "" {
+		w.writeAttrs([]xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: xmlns}})
+	}
+	w.writeAttrs(r.Attrs)
+	w.buf.WriteByte('>')
+	w.buf.Write(r.Inner)
+	w.buf.WriteString("</")
+	w.buf.WriteString(r.XMLName.Local)
+	w.buf.WriteByte('>')
+}
+
+// writeAttrs escreve os atributos, restaurando os prefixos de namespace
+func (w *xmlWriter) writeAttrs(attrs []xml.Attr) {
+	for _, a := range attrs {
+		w.buf.WriteByte(' ')
+		switch {
+		case a.Name.Space == "":
+		case a.Name.Space == "xmlns":
+			w.buf.WriteString("xmlns:")
+		case a.Name.Space == "http://www.w3.org/XML/1998/namespace":
+			w.buf.WriteString("xml:")
+		case w.prefixes[a.Name.Space] != "":
+			w.buf.WriteString(w.prefixes[a.Name.Space])
+			w.buf.WriteByte(':')
+		}
+		w.buf.WriteString(a.Name.Local)
+		w.buf.WriteString(`="`)
+		escape(&w.buf, a.Value, true)
+		w.buf.WriteByte('"')
+	}
+}
+
+// escape escreve o texto com os caracteres especiais do XML escapados
+func escape(buf *bytes.Buffer, s string, attr bool) {
+	for _, r := range s {
+		switch {
+		case r == '&':
+			buf.WriteString("&amp;")
+		case r == '<':
+			buf.WriteString("&lt;")
+		case r == '>':
+			buf.WriteString("&gt;")
+		case r == '"' && attr:
+			buf.WriteString("&quot;")
+		case r == '\r':
+			buf.WriteString("&#xD;")
+		case (r == '\n' || r == '\t') && attr:
+			fmt.Fprintf(buf, "&#x%X;", r)
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
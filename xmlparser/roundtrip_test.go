@@ -0,0 +1,214 @@
+package xmlparser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// readTestdata lê um arquivo de testdata
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// canonical reduz o XML a uma lista de tokens comparável: atributos
+// ordenados, namespaces resolvidos e texto sem espaços de indentação
+func canonical(t *testing.T, data []byte) []string {
+	t.Helper()
+	d := xml.NewDecoder(bytes.NewReader(data))
+	var tokens []string
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("XML inválido: %v", err)
+			}
+			return tokens
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			var attrs []string
+			for _, a := range tok.Attr {
+				if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") {
+					continue
+				}
+				attrs = append(attrs, a.Name.Space+":"+a.Name.Local+"="+a.Value)
+			}
+			sort.Strings(attrs)
+			tokens = append(tokens, "<"+tok.Name.Space+" "+tok.Name.Local+" "+strings.Join(attrs, " "))
+		case xml.EndElement:
+			tokens = append(tokens, "</"+tok.Name.Local)
+		case xml.CharData:
+			if text := strings.TrimSpace(string(tok)); text != "" {
+				tokens = append(tokens, "#"+text)
+			}
+		}
+	}
+}
+
+// assertSameXML falha quando os dois XML não são semanticamente idênticos
+func assertSameXML(t *testing.T, want, got []byte) {
+	t.Helper()
+	a, b := canonical(t, want), canonical(t, got)
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			t.Fatalf("token %d: esperado %q, obtido %q\n%s", i, a[i], b[i], got)
+		}
+	}
+	if len(a) != len(b) {
+		t.Fatalf("esperados %d tokens, obtidos %d\n%s", len(a), len(b), got)
+	}
+}
+
+func TestMarshalPreservesSignatureAndInfRespTec(t *testing.T) {
+	input := readTestdata(t, "nfce.xml")
+	nfe, err := ParseXMLWithOptions(input, ParseOptions{Preserve: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opts := range []MarshalOptions{{Header: true}, {Indent: "  ", Header: true}} {
+		out, err := nfe.Marshal(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertSameXML(t, input, out)
+
+		for _, want := range []string{
+			`<Signature xmlns="http://www.w3.org/2000/09/xmldsig#">`,
+			`<SignatureValue>abc</SignatureValue>`,
+			`<infRespTec>`,
+			`<xContato>Fulano</xContato>`,
+		} {
+			if !bytes.Contains(out, []byte(want)) {
+				t.Errorf("Marshal(%+v) sem %s", opts, want)
+			}
+		}
+	}
+}
+
+func TestMarshalPreservesEventSignature(t *testing.T) {
+	input := readTestdata(t, "cancelamento.xml")
+	ev, err := ParseEventoXMLWithOptions(input, ParseOptions{Preserve: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ev.Marshal(MarshalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameXML(t, input, out)
+}
+
+func TestMarshalWithoutPreserve(t *testing.T) {
+	nfe, err := ParseXML(readTestdata(t, "nfce.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := nfe.Marshal(MarshalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, unknown := range []string{"<Signature", "<infRespTec>"} {
+		if bytes.Contains(out, []byte(unknown)) {
+			t.Errorf("Marshal sem Preserve escreveu %s", unknown)
+		}
+	}
+
+	again, err := ParseXML(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.GetChaveAcesso() != nfe.GetChaveAcesso() || !again.NFe.InfNFe.Ide.DHEmi.Equal(nfe.NFe.InfNFe.Ide.DHEmi) {
+		t.Errorf("XML reescrito difere do original:\n%s", out)
+	}
+}
+
+func TestParseXMLRejectsOtherRoot(t *testing.T) {
+	input := readTestdata(t, "cancelamento.xml")
+	for _, preserve := range []bool{false, true} {
+		nfe, err := ParseXMLWithOptions(input, ParseOptions{Preserve: preserve})
+		if err == nil {
+			t.Fatalf("Preserve=%v: esperado erro, obtido %+v", preserve, nfe)
+		}
+		if !errors.Is(err, ErrMalformedXML) {
+			t.Errorf("Preserve=%v: esperado ErrMalformedXML, obtido %v", preserve, err)
+		}
+		if !strings.Contains(err.Error(), "<nfeProc>") {
+			t.Errorf("Preserve=%v: mensagem sem o elemento esperado: %v", preserve, err)
+		}
+	}
+
+	if _, err := ParseEventoXMLWithOptions(readTestdata(t, "nfce.xml"), ParseOptions{Preserve: true}); !errors.Is(err, ErrMalformedXML) {
+		t.Errorf("esperado ErrMalformedXML para evento com raiz nfeProc, obtido %v", err)
+	}
+}
+
+func TestParseXMLPreserveSameModel(t *testing.T) {
+	input := readTestdata(t, "nfce.xml")
+	plain, err := ParseXML(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	preserved, err := ParseXMLWithOptions(input, ParseOptions{Preserve: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// O parse que preserva o XML não preenche XMLName
+	plain.NFe.XMLName = xml.Name{}
+	if !reflect.DeepEqual(plain.NFe, preserved.NFe) || !reflect.DeepEqual(plain.ProtNFe, preserved.ProtNFe) {
+		t.Errorf("modelos diferentes:\n%+v\n%+v", plain.NFe, preserved.NFe)
+	}
+}
+
+func TestParseXMLVersions(t *testing.T) {
+	for _, name := range []string{"nfe-310.xml", "nfe-sem-protocolo.xml"} {
+		t.Run(name, func(t *testing.T) {
+			input := readTestdata(t, name)
+			plain, err := ParseXML(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			preserved, err := ParseXMLWithOptions(input, ParseOptions{Preserve: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			a, b := plain.NFe.InfNFe.Ide, preserved.NFe.InfNFe.Ide
+			if a.DHEmi.IsZero() || !a.DHEmi.Equal(b.DHEmi) {
+				t.Errorf("dhEmi: %v e %v", a.DHEmi, b.DHEmi)
+			}
+			if (a.DHSaiEnt == nil) != (b.DHSaiEnt == nil) || (a.DHSaiEnt != nil && !a.DHSaiEnt.Equal(*b.DHSaiEnt)) {
+				t.Errorf("dhSaiEnt: %v e %v", a.DHSaiEnt, b.DHSaiEnt)
+			}
+
+			out, err := preserved.Marshal(MarshalOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertSameXML(t, input, out)
+
+			// Sem Preserve, o XML sem nfeProc continua sem nfeProc
+			out, err = plain.Marshal(MarshalOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if root := rootElement(out); root != rootElement(input) {
+				t.Errorf("raiz %s, esperada %s", root, rootElement(input))
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package xmlparser
+
+// AuthorizationStatus representa a situação da NF-e segundo o protocolo da SEFAZ
+type AuthorizationStatus int
+
+const (
+	// StatusMissing indica que o XML não contém protocolo de autorização
+	StatusMissing AuthorizationStatus = iota
+	// StatusAuthorized indica uso autorizado (cStat 100 ou 150)
+	StatusAuthorized
+	// StatusDenied indica uso denegado (cStat 110, 205, 301, 302 ou 303)
+	StatusDenied
+	// StatusRejected indica qualquer outro retorno da SEFAZ
+	StatusRejected
+)
+
+// String retorna a descrição da situação
+func (s AuthorizationStatus) String() string {
+	switch s {
+	case StatusAuthorized:
+		return "autorizada"
+	case StatusDenied:
+		return "denegada"
+	case StatusRejected:
+		return "rejeitada"
+	default:
+		return "sem protocolo"
+	}
+}
+
+// ClassifyStatus classifica o cStat do protocolo de autorização
+func ClassifyStatus(cStat string) AuthorizationStatus {
+	switch cStat {
+	case "":
+		return StatusMissing
+	case "100", "150":
+		return StatusAuthorized
+	case "110", "205", "301", "302", "303":
+		return StatusDenied
+	default:
+		return StatusRejected
+	}
+}
+
+// GetStatus retorna a situação da NF-e de acordo com o protocolo
+func (nfe *NFeProc) GetStatus() AuthorizationStatus {
+	return ClassifyStatus(nfe.ProtNFe.InfProt.CStat)
+}
+
+// Textos exigidos pelo Manual do DANFE NFC-e em ambiente de homologação
+const (
+	MensagemHomologacao = "EMITIDA EM AMBIENTE DE HOMOLOGAÇÃO – SEM VALOR FISCAL"
+	XProdHomologacao    = "NOTA FISCAL EMITIDA EM AMBIENTE DE HOMOLOGACAO - SEM VALOR FISCAL"
+	XNomeHomologacao    = "NF-E EMITIDA EM AMBIENTE DE HOMOLOGACAO - SEM VALOR FISCAL"
+)
+
+// IsHomologacao verifica se a NF-e foi emitida em ambiente de homologação (tpAmb 2)
+func (nfe *NFeProc) IsHomologacao() bool {
+	return nfe.NFe.InfNFe.Ide.TpAmb == "2" || nfe.ProtNFe.InfProt.TpAmb == "2"
+}
+
+// IsContingenciaOffline verifica se a NFC-e foi emitida em contingência offline (tpEmis 9)
+func (nfe *NFeProc) IsContingenciaOffline() bool {
+	return nfe.NFe.InfNFe.Ide.TpEmis == "9"
+}